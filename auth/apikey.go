@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// APIKeyPrefixLength is how much of a key is kept in the clear, so users can
+// tell their keys apart.
+const APIKeyPrefixLength = 10
+
+const apiKeyMarker = "rk_"
+
+// NewAPIKey returns a new random API key.
+func NewAPIKey() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyMarker + hex.EncodeToString(secret), nil
+}
+
+// HashAPIKey returns the hash API keys are stored and looked up by. Keys are
+// random and long, so unlike passwords they do not need a slow hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	key := []byte("secret")
+	valid, _, err := NewToken("user1", time.Hour, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := NewToken("user1", -time.Minute, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	encode := func(text string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(text))
+	}
+	// signed signs a token with the given header and payload, so only the
+	// part under test is wrong.
+	signed := func(header string, payload string) string {
+		unsigned := header + "." + payload
+		return unsigned + "." + sign(unsigned, key)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		key   []byte
+		want  error
+	}{
+		{"valid", valid, key, nil},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + sign("something else", key), key, ErrInvalidToken},
+		{"tampered payload", parts[0] + "." + encode(`{"sub":"admin","iat":0,"exp":9999999999}`) + "." + parts[2], key, ErrInvalidToken},
+		{"other key", valid, []byte("other"), ErrInvalidToken},
+		{"alg none", encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + ".", key, ErrInvalidToken},
+		{"alg HS512", signed(encode(`{"alg":"HS512","typ":"JWT"}`), parts[1]), key, ErrInvalidToken},
+		{"expired", expired, key, ErrExpiredToken},
+		{"empty", "", key, ErrInvalidToken},
+		{"two parts", parts[0] + "." + parts[1], key, ErrInvalidToken},
+		{"four parts", valid + ".x", key, ErrInvalidToken},
+		{"payload not base64", signed(tokenHeader, "%%%"), key, ErrInvalidToken},
+		{"payload not JSON", signed(tokenHeader, encode("not json")), key, ErrInvalidToken},
+		{"no subject", signed(tokenHeader, encode(`{"iat":0,"exp":9999999999}`)), key, ErrInvalidToken},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := ParseToken(test.token, test.key)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("ParseToken = %+v, %v, want error %v", claims, err, test.want)
+			}
+			if test.want == nil && claims.Subject != "user1" {
+				t.Errorf("Subject = %q, want user1", claims.Subject)
+			}
+		})
+	}
+}
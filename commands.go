@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"rest/database"
+	"rest/nutrition"
+	"strings"
+)
+
+const usage = `usage: rest [command]
+
+Without a command, the server is started.
+
+commands:
+  import-nutrition FILE   set the nutrition facts of ingredients from a CSV
+                          file with one row per food, values per 100 g`
+
+// runCommand runs a command given on the command line instead of starting
+// the server.
+func runCommand(store database.Store, args []string) error {
+	switch args[0] {
+	case "import-nutrition":
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		return importNutrition(store, args[1])
+	default:
+		return errors.New(usage)
+	}
+}
+
+// importNutrition sets the nutrition facts of every ingredient that matches
+// a food in the CSV file at path, see nutrition.Match.
+func importNutrition(store database.Store, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	records, err := nutrition.ReadCSV(file)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	ingredients := store.AllIngredients()
+	if ingredients == nil {
+		return errors.New("failed to load ingredients")
+	}
+	var unmatched []string
+	updated := 0
+	for _, ingredient := range ingredients {
+		record, ok := nutrition.Match(records, ingredient.Name)
+		if !ok {
+			unmatched = append(unmatched, ingredient.Name)
+			continue
+		}
+		input := ingredient.WithoutID()
+		input.Nutrition = &record.Nutrition
+		if _, err := store.UpdateIngredient(ingredient.ID, &input); err != nil {
+			return fmt.Errorf("updating %s: %w", ingredient.Name, err)
+		}
+		fmt.Printf("%s: %s\n", ingredient.Name, record.Name)
+		updated++
+	}
+	fmt.Printf("updated %d of %d ingredients from %d foods\n", updated, len(ingredients), len(records))
+	if len(unmatched) > 0 {
+		fmt.Printf("no nutrition facts for: %s\n", strings.Join(unmatched, ", "))
+	}
+	return nil
+}
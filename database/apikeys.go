@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureAPIKeyIndexes indexes keys by their hash, which every request made
+// with a key is looked up by.
+func (db *DB) ensureAPIKeyIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := db.apiKeyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "keyhash", Value: 1}},
+		Options: options.Index().SetName("keyhash_unique").SetUnique(true),
+	})
+	if err != nil {
+		log.Print("failed to create API key index: ", err)
+	}
+}
+
+func (db *DB) SaveAPIKey(key *model.APIKey) error {
+	key.ID = primitive.NewObjectID().Hex()
+	return insertDocument(db.apiKeyCollection, key)
+}
+
+func (db *DB) AllAPIKeys() []*model.APIKey {
+	keys, err := findDocuments[model.APIKey](db.apiKeyCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return keys
+}
+
+func (db *DB) FindAPIKeyByID(ID string) *model.APIKey {
+	key, err := findDocument[model.APIKey](db.apiKeyCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return key
+}
+
+func (db *DB) FindAPIKeyByHash(hash string) *model.APIKey {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key := model.APIKey{}
+	err := db.apiKeyCollection.FindOne(ctx, bson.M{"keyhash": hash}).Decode(&key)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return &key
+}
+
+func (db *DB) RevokeAPIKey(ID string, at time.Time) error {
+	return db.updateAPIKey(ID, bson.M{"$set": bson.M{"revokedat": at}})
+}
+
+func (db *DB) RecordAPIKeyUse(ID string, at time.Time) error {
+	return db.updateAPIKey(ID, bson.M{"$inc": bson.M{"usage": 1}, "$set": bson.M{"lastusedat": at}})
+}
+
+func (db *DB) updateAPIKey(ID string, update bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := db.apiKeyCollection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
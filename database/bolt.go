@@ -0,0 +1,78 @@
+package database
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// OpenBolt returns a Store that keeps its data in an embedded bbolt file at
+// path, creating the file if it does not exist.
+func OpenBolt(path string) (*KVStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range kvBuckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	store := &KVStore{backend: &boltBackend{db: db}}
+	if err := store.migrateIngredientLines(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+type boltBackend struct {
+	db *bolt.DB
+}
+
+func (b *boltBackend) view(fn func(tx kvTx) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (b *boltBackend) update(fn func(tx kvTx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t *boltTx) get(bucket string, key string) []byte {
+	value := t.tx.Bucket([]byte(bucket)).Get([]byte(key))
+	if value == nil {
+		return nil
+	}
+	// Values returned by bbolt are only valid for the life of the
+	// transaction.
+	return append([]byte(nil), value...)
+}
+
+func (t *boltTx) put(bucket string, key string, value []byte) error {
+	return t.tx.Bucket([]byte(bucket)).Put([]byte(key), value)
+}
+
+func (t *boltTx) delete(bucket string, key string) error {
+	return t.tx.Bucket([]byte(bucket)).Delete([]byte(key))
+}
+
+func (t *boltTx) forEach(bucket string, fn func(key string, value []byte) error) error {
+	return t.tx.Bucket([]byte(bucket)).ForEach(func(key []byte, value []byte) error {
+		return fn(string(key), append([]byte(nil), value...))
+	})
+}
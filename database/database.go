@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"rest/model"
 
 	"fmt"
@@ -16,9 +17,18 @@ import (
 )
 
 type DB struct {
-	client               *mongo.Client
-	recipeCollection     *mongo.Collection
-	ingredientCollection *mongo.Collection
+	client                 *mongo.Client
+	recipeCollection       *mongo.Collection
+	trashCollection        *mongo.Collection
+	ingredientCollection   *mongo.Collection
+	shoppingCollection     *mongo.Collection
+	mealPlanCollection     *mongo.Collection
+	pantryCollection       *mongo.Collection
+	userCollection         *mongo.Collection
+	apiKeyCollection       *mongo.Collection
+	revisionCollection     *mongo.Collection
+	substitutionCollection *mongo.Collection
+	settingsCollection     *mongo.Collection
 }
 
 func getEnv(key, defaultValue string) string {
@@ -29,13 +39,13 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// derefString returns defaultValue if str is nil, otherwise returns *str
-func derefValue[T any](value *T, defaultValue T) T {
-	if value != nil {
-		return *value
-	}
-	return defaultValue
-}
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrUsernameTaken = errors.New("username is taken")
+	// ErrVersionMismatch is returned when a recipe changed since the
+	// version the caller wants to change.
+	ErrVersionMismatch = errors.New("recipe has been changed since")
+)
 
 func Connect() *DB {
 	DB_HOST := getEnv("DB_HOST", "localhost")
@@ -49,9 +59,24 @@ func Connect() *DB {
 		panic(err)
 	}
 	recipeCollection := client.Database("data").Collection("recipes")
+	trashCollection := client.Database("data").Collection("recipes_trash")
 	ingredientCollection := client.Database("data").Collection("ingredients")
-
-	return &DB{client: client, recipeCollection: recipeCollection, ingredientCollection: ingredientCollection}
+	shoppingCollection := client.Database("data").Collection("shopping_lists")
+	mealPlanCollection := client.Database("data").Collection("meal_plans")
+	pantryCollection := client.Database("data").Collection("pantry")
+	userCollection := client.Database("data").Collection("users")
+	apiKeyCollection := client.Database("data").Collection("api_keys")
+	revisionCollection := client.Database("data").Collection("recipe_revisions")
+	substitutionCollection := client.Database("data").Collection("substitutions")
+	settingsCollection := client.Database("data").Collection("settings")
+
+	db := &DB{client: client, recipeCollection: recipeCollection, trashCollection: trashCollection, ingredientCollection: ingredientCollection, shoppingCollection: shoppingCollection, mealPlanCollection: mealPlanCollection, pantryCollection: pantryCollection, userCollection: userCollection, apiKeyCollection: apiKeyCollection, revisionCollection: revisionCollection, substitutionCollection: substitutionCollection, settingsCollection: settingsCollection}
+	db.ensureTextIndexes()
+	db.ensureUserIndexes()
+	db.ensureAPIKeyIndexes()
+	db.ensureRevisionIndexes()
+	db.migrateIngredientLines()
+	return db
 }
 
 func (db *DB) SaveIngredientWithID(input *model.Ingredient) *model.Ingredient {
@@ -64,21 +89,18 @@ func (db *DB) SaveIngredientWithID(input *model.Ingredient) *model.Ingredient {
 		return nil
 	}
 	ingredientWithObjectID := &struct {
-		ID   primitive.ObjectID `json:"_id" bson:"_id"`
-		Name string             `json:"name"`
+		ID                        primitive.ObjectID `json:"_id" bson:"_id"`
+		model.IngredientWithoutID `bson:",inline"`
 	}{
-		ID:   objectID,
-		Name: input.Name,
+		ID:                  objectID,
+		IngredientWithoutID: input.WithoutID(),
 	}
 	_, err := db.ingredientCollection.InsertOne(ctx, ingredientWithObjectID)
 	if err != nil {
 		log.Print(err)
 		return nil
 	}
-	return &model.Ingredient{
-		ID:   input.ID,
-		Name: input.Name,
-	}
+	return ingredientWithObjectID.WithID(input.ID)
 }
 
 func (db *DB) SaveIngredient(input *model.IngredientWithoutID) *model.Ingredient {
@@ -90,14 +112,11 @@ func (db *DB) SaveIngredient(input *model.IngredientWithoutID) *model.Ingredient
 		log.Print(err)
 		return nil
 	}
-	return &model.Ingredient{
-		ID:   res.InsertedID.(primitive.ObjectID).Hex(),
-		Name: input.Name,
-	}
+	return input.WithID(res.InsertedID.(primitive.ObjectID).Hex())
 }
 
 func (db *DB) SaveRecipe(input *model.RecipeWithoutID) *model.Recipe {
-
+	input.Version = 1
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	res, err := db.recipeCollection.InsertOne(ctx, input)
@@ -107,12 +126,15 @@ func (db *DB) SaveRecipe(input *model.RecipeWithoutID) *model.Recipe {
 	}
 
 	return &model.Recipe{
-		ID:          res.InsertedID.(primitive.ObjectID).Hex(),
-		Name:        input.Name,
-		Description: input.Description,
-		Category:    input.Category,
-		Steps:       input.Steps,
-		Ingredients: input.Ingredients,
+		ID:              res.InsertedID.(primitive.ObjectID).Hex(),
+		Name:            input.Name,
+		Description:     input.Description,
+		Category:        input.Category,
+		Servings:        input.Servings,
+		OwnerID:         input.OwnerID,
+		Steps:           input.Steps,
+		IngredientLines: input.IngredientLines,
+		Version:         input.Version,
 	}
 }
 
@@ -154,27 +176,70 @@ func (db *DB) FindRecipeByID(ID string) *model.ResolvedRecipe {
 	recipe := model.Recipe{}
 	res.Decode(&recipe)
 
-	ingredientsResult, err := db.ingredientCollection.Find(ctx, bson.M{"_id": bson.M{"$in": recipe.Ingredients}})
+	ingredientsResult, err := db.ingredientCollection.Find(ctx, bson.M{"_id": bson.M{"$in": model.IngredientIDs(recipe.IngredientLines)}})
 	if err != nil {
-		fmt.Errorf("could not resolve ingredients for recipe.")
+		log.Print("could not resolve ingredients for recipe: ", err)
 		return nil
 
 	}
 	ingredients := []model.Ingredient{}
-	ingredientsResult.Decode(&ingredients)
+	if err := ingredientsResult.All(ctx, &ingredients); err != nil {
+		log.Print("could not resolve ingredients for recipe: ", err)
+		return nil
+	}
+	// $in returns the ingredients in no particular order and each only
+	// once, so the lines are resolved by ID.
+	byID := make(map[string]model.Ingredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		byID[ingredient.ID] = ingredient
+	}
+	lines := make([]model.ResolvedIngredientLine, 0, len(recipe.IngredientLines))
+	unresolved := 0
+	for _, line := range recipe.IngredientLines {
+		if ingredient, ok := byID[line.Ingredient.Hex()]; ok {
+			lines = append(lines, line.Resolve(ingredient))
+		} else {
+			unresolved++
+		}
+	}
 	resolvedRecipe := model.ResolvedRecipe{
 		ID:              recipe.ID,
 		Description:     recipe.Description,
 		Name:            recipe.Name,
 		Category:        recipe.Category,
+		Servings:        recipe.Servings,
+		OwnerID:         recipe.OwnerID,
 		Steps:           recipe.Steps,
-		Ingredients:     ingredients,
-		IngredientsMeta: recipe.IngredientsMeta,
+		IngredientLines: lines,
+		Version:         recipe.Version,
+		Classification:  model.ClassifyRecipe(lines, unresolved),
 	}
 
 	return &resolvedRecipe
 }
 
+// FindRecipeDocumentByID returns the stored recipe without resolving its
+// ingredients.
+func (db *DB) FindRecipeDocumentByID(ID string) *model.Recipe {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res := db.recipeCollection.FindOne(ctx, bson.M{"_id": ObjectID})
+	if res.Err() != nil {
+		return nil
+	}
+	recipe := model.Recipe{}
+	if err := res.Decode(&recipe); err != nil {
+		log.Print(err)
+		return nil
+	}
+
+	return &recipe
+}
+
 func (db *DB) FindRecipeByName(name string) *model.Recipe {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -193,6 +258,27 @@ func (db *DB) FindIngredientByName(name string) *model.Ingredient {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	res := db.ingredientCollection.FindOne(ctx, bson.M{"name": name})
+	if res.Err() != nil {
+		// Names are compared without case and plurals, which the
+		// collection cannot index, so fall back to checking each
+		// ingredient.
+		return model.FindIngredientByName(db.AllIngredients(), name)
+	}
+	ingredient := model.Ingredient{}
+
+	res.Decode(&ingredient)
+
+	return &ingredient
+}
+
+func (db *DB) FindIngredientByID(ID string) *model.Ingredient {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res := db.ingredientCollection.FindOne(ctx, bson.M{"_id": ObjectID})
 	if res.Err() != nil {
 		return nil
 	}
@@ -211,7 +297,7 @@ func (db *DB) AllIngredients() []*model.Ingredient {
 		log.Print(err)
 		return nil
 	}
-	var ingredients []*model.Ingredient
+	ingredients := []*model.Ingredient{}
 	for cur.Next(ctx) {
 		var ingredient *model.Ingredient
 		err := cur.Decode(&ingredient)
@@ -232,7 +318,7 @@ func (db *DB) AllRecipes() []*model.Recipe {
 		log.Print(err)
 		return nil
 	}
-	var recipes []*model.Recipe
+	recipes := []*model.Recipe{}
 	for cur.Next(ctx) {
 		var recipe *model.Recipe
 		err := cur.Decode(&recipe)
@@ -245,54 +331,267 @@ func (db *DB) AllRecipes() []*model.Recipe {
 	return recipes
 }
 
-// func (db *DB) UpdateRecipe(newRecipe *model.UpdateRecipeInput) (*model.Recipe, error) {
-// 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-// 	defer cancel()
-
-// 	originalRecipe := db.FindRecipeByID(newRecipe.ID)
-// 	if originalRecipe == nil {
-// 		fmt.Errorf("Could not get original recipe")
-// 		return nil, nil
-// 	}
-
-// 	var ingredients []*model.Ingredient = make([]*model.Ingredient, len(newRecipe.Ingredients))
-// 	for i, ing := range newRecipe.Ingredients {
-// 		ingredient := model.Ingredient{
-// 			ID:       ing.ID,
-// 			Name:     derefValue(ing.Name, originalRecipe.Ingredients[i].Name),
-// 			Quantity: derefValue(ing.Quantity, originalRecipe.Ingredients[i].Quantity),
-// 			Unit:     derefValue(ing.Unit, originalRecipe.Ingredients[i].Unit),
-// 		}
-// 		ingredients[i] = &ingredient
-// 	}
-
-// 	recipe := model.Recipe{
-// 		ID:          newRecipe.ID,
-// 		Name:        derefValue(newRecipe.Name, originalRecipe.Name),
-// 		Description: derefValue(newRecipe.Description, originalRecipe.Description),
-// 		Category:    derefValue(newRecipe.Category, originalRecipe.Category),
-// 		Steps:       newRecipe.Steps,
-// 		Ingredients: ingredients,
-// 	}
-// 	ObjectID, err := primitive.ObjectIDFromHex(newRecipe.ID)
-// 	res, err := db.recipeCollection.UpdateOne(ctx, bson.M{"_id": ObjectID}, bson.M{"$set": recipe})
-// 	fmt.Println(res)
-// 	if err != nil {
-// 		fmt.Errorf("Failed to update recipe")
-// 		return nil, nil
-// 	}
-
-// 	return &recipe, nil
-// }
-
-func (db *DB) DeleteRecipe(ID string) (bool, error) {
+func (db *DB) UpdateRecipe(ID string, version int, input *model.RecipeWithoutID) (*model.ResolvedRecipe, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	input.Version = version + 1
+	res, err := db.recipeCollection.ReplaceOne(ctx, bson.M{"_id": ObjectID, "version": versionFilter(version)}, input)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, db.recipeMismatch(ctx, ObjectID)
+	}
+
+	return db.FindRecipeByID(ID), nil
+}
+
+// versionFilter matches recipes at version. Recipes stored before versions
+// were kept have no version field, which counts as version 0.
+func versionFilter(version int) any {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
 
+// recipeMismatch explains why a write conditional on a recipe's version
+// matched nothing: the recipe is gone, or it is at another version.
+func (db *DB) recipeMismatch(ctx context.Context, ObjectID primitive.ObjectID) error {
+	count, err := db.recipeCollection.CountDocuments(ctx, bson.M{"_id": ObjectID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// DeleteRecipe moves a recipe to the trash collection. It returns false if
+// no recipe with the given ID exists.
+func (db *DB) DeleteRecipe(ID string, version int) (bool, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return false, nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	_, err := db.recipeCollection.DeleteOne(ctx, bson.M{"_id": ID})
+
+	filter := bson.M{"_id": ObjectID, "version": versionFilter(version)}
+	var recipe bson.M
+	err = db.recipeCollection.FindOne(ctx, filter).Decode(&recipe)
+	if err == mongo.ErrNoDocuments {
+		if err := db.recipeMismatch(ctx, ObjectID); err != ErrNotFound {
+			return false, err
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	recipe["deletedat"] = time.Now().UTC()
+	if _, err := db.trashCollection.InsertOne(ctx, recipe); err != nil {
+		return false, err
+	}
+	res, err := db.recipeCollection.DeleteOne(ctx, filter)
+	if err != nil || res.DeletedCount == 0 {
+		// Undo the copy so the recipe does not end up in both collections.
+		db.trashCollection.DeleteOne(ctx, bson.M{"_id": ObjectID})
+		if err == nil {
+			// The recipe changed after it was read.
+			err = ErrVersionMismatch
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (db *DB) AllTrashedRecipes() []*model.TrashedRecipe {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cur, err := db.trashCollection.Find(ctx, bson.D{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	recipes := []*model.TrashedRecipe{}
+	for cur.Next(ctx) {
+		var recipe *model.TrashedRecipe
+		err := cur.Decode(&recipe)
+		if err != nil {
+			log.Print(err)
+			return nil
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes
+}
+
+func (db *DB) FindTrashedRecipe(ID string) (*model.TrashedRecipe, error) {
+	return findDocument[model.TrashedRecipe](db.trashCollection, ID)
+}
+
+// RestoreRecipe moves a recipe from the trash back to the recipe collection.
+// It returns false if the recipe is not in the trash.
+func (db *DB) RestoreRecipe(ID string) (bool, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
 	if err != nil {
 		return false, nil
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var recipe bson.M
+	err = db.trashCollection.FindOne(ctx, bson.M{"_id": ObjectID}).Decode(&recipe)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	delete(recipe, "deletedat")
+	if _, err := db.recipeCollection.InsertOne(ctx, recipe); err != nil {
+		return false, err
+	}
+	if _, err := db.trashCollection.DeleteOne(ctx, bson.M{"_id": ObjectID}); err != nil {
+		db.recipeCollection.DeleteOne(ctx, bson.M{"_id": ObjectID})
+		return false, err
+	}
 
 	return true, nil
 }
+
+// PurgeTrash permanently removes recipes that were moved to the trash before
+// the given time, and returns how many were removed.
+func (db *DB) PurgeTrash(before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res, err := db.trashCollection.DeleteMany(ctx, bson.M{"deletedat": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (db *DB) UpdateIngredient(ID string, input *model.IngredientWithoutID) (*model.Ingredient, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := db.ingredientCollection.ReplaceOne(ctx, bson.M{"_id": ObjectID}, input)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrNotFound
+	}
+
+	return input.WithID(ID), nil
+}
+
+func (db *DB) RecipesUsingIngredient(ID string) ([]*model.Recipe, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var recipes []*model.Recipe
+	for _, collection := range []*mongo.Collection{db.recipeCollection, db.trashCollection} {
+		cur, err := collection.Find(ctx, bson.M{"ingredientlines.ingredient": ObjectID})
+		if err != nil {
+			return nil, err
+		}
+		var found []*model.Recipe
+		if err := cur.All(ctx, &found); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, found...)
+	}
+	return recipes, nil
+}
+
+func (db *DB) DeleteIngredient(ID string) (bool, error) {
+	ObjectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := db.ingredientCollection.DeleteOne(ctx, bson.M{"_id": ObjectID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (db *DB) ListRecipes(query model.ListQuery) ([]*model.Recipe, bool, error) {
+	filter := bson.M{}
+	if query.Category != "" {
+		filter["category"] = query.Category
+	}
+	if query.Ingredient != nil {
+		filter["ingredientlines.ingredient"] = *query.Ingredient
+	}
+	if query.AllowedIngredients != nil {
+		filter["ingredientlines"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{"ingredient": bson.M{"$nin": query.AllowedIngredients}}}}
+	}
+	return findPage[model.Recipe](db.recipeCollection, filter, query)
+}
+
+func (db *DB) ListIngredients(query model.ListQuery) ([]*model.Ingredient, bool, error) {
+	return findPage[model.Ingredient](db.ingredientCollection, bson.M{}, query)
+}
+
+// findPage returns one page of documents matching filter and whether more
+// documents follow. It fetches one extra document to find out.
+func findPage[T any](collection *mongo.Collection, filter bson.M, query model.ListQuery) ([]*T, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	direction, comparison := 1, "$gt"
+	if query.Descending {
+		direction, comparison = -1, "$lt"
+	}
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if query.SortField == model.SortByName {
+		sort = bson.D{{Key: "name", Value: direction}, {Key: "_id", Value: direction}}
+	}
+	if query.After != nil {
+		afterID, err := primitive.ObjectIDFromHex(query.After.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		if query.SortField == model.SortByName {
+			filter["$or"] = bson.A{
+				bson.M{"name": bson.M{comparison: query.After.Name}},
+				bson.M{"name": query.After.Name, "_id": bson.M{comparison: afterID}},
+			}
+		} else {
+			filter["_id"] = bson.M{comparison: afterID}
+		}
+	}
+
+	cur, err := collection.Find(ctx, filter, options.Find().SetSort(sort).SetLimit(int64(query.Limit)+1))
+	if err != nil {
+		return nil, false, err
+	}
+	documents := []*T{}
+	if err := cur.All(ctx, &documents); err != nil {
+		return nil, false, err
+	}
+	if len(documents) > query.Limit {
+		return documents[:query.Limit], true, nil
+	}
+	return documents, false, nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The helpers below store model documents whose ID is a hex string in
+// collections keyed by ObjectID, like the recipes and ingredients.
+
+// withObjectID converts a document to the bson stored in Mongo, with its
+// hex _id replaced by the ObjectID it encodes.
+func withObjectID(document any) (bson.M, error) {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var stored bson.M
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	ID, _ := stored["_id"].(string)
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, err
+	}
+	stored["_id"] = objectID
+	return stored, nil
+}
+
+func insertDocument(collection *mongo.Collection, document any) error {
+	stored, err := withObjectID(document)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = collection.InsertOne(ctx, stored)
+	return err
+}
+
+// replaceDocument overwrites the document with the same ID, and returns
+// ErrNotFound if there is none.
+func replaceDocument(collection *mongo.Collection, document any) error {
+	stored, err := withObjectID(document)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := collection.ReplaceOne(ctx, bson.M{"_id": stored["_id"]}, stored)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// findDocument returns the document with the given ID, or ErrNotFound.
+func findDocument[T any](collection *mongo.Collection, ID string) (*T, error) {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var document T
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// findDocuments returns every document matching filter, oldest first.
+func findDocuments[T any](collection *mongo.Collection, filter bson.M) ([]*T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	documents := []*T{}
+	if err := cur.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func deleteDocument(collection *mongo.Collection, ID string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
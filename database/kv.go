@@ -0,0 +1,473 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"rest/model"
+	"rest/search"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	recipeBucket       = "recipes"
+	trashBucket        = "recipes_trash"
+	ingredientBucket   = "ingredients"
+	shoppingBucket     = "shopping_lists"
+	mealPlanBucket     = "meal_plans"
+	pantryBucket       = "pantry"
+	userBucket         = "users"
+	apiKeyBucket       = "api_keys"
+	revisionBucket     = "recipe_revisions"
+	substitutionBucket = "substitutions"
+)
+
+var kvBuckets = []string{recipeBucket, trashBucket, ingredientBucket, shoppingBucket, mealPlanBucket, pantryBucket, userBucket, apiKeyBucket, revisionBucket, substitutionBucket}
+
+var (
+	errDuplicateKey = errors.New("a document with this ID already exists")
+	errReadOnly     = errors.New("write in a read-only transaction")
+	errLoadFailed   = errors.New("failed to load documents")
+)
+
+// kvTx is a transaction on a key-value backend. Values are JSON documents
+// keyed by their hex ObjectID, grouped in buckets.
+type kvTx interface {
+	get(bucket string, key string) []byte
+	put(bucket string, key string, value []byte) error
+	delete(bucket string, key string) error
+	// forEach calls fn for every document in the bucket in ascending key
+	// order. ObjectIDs start with their creation time, so this lists
+	// documents oldest first like a Mongo collection scan.
+	forEach(bucket string, fn func(key string, value []byte) error) error
+}
+
+type kvBackend interface {
+	view(fn func(tx kvTx) error) error
+	update(fn func(tx kvTx) error) error
+}
+
+// KVStore implements Store on top of a key-value backend. It gives the
+// in-memory and embedded file backends the same semantics as the Mongo DB.
+type KVStore struct {
+	backend kvBackend
+
+	// searchIndex is built on the first search after a write and dropped by
+	// every write.
+	searchMu    sync.Mutex
+	searchIndex *search.Index
+}
+
+// update runs fn in a write transaction and invalidates the search index.
+func (s *KVStore) update(fn func(tx kvTx) error) error {
+	defer s.invalidateSearchIndex()
+	return s.backend.update(fn)
+}
+
+func getDocument[T any](tx kvTx, bucket string, key string) *T {
+	value := tx.get(bucket, key)
+	if value == nil {
+		return nil
+	}
+	var document T
+	if err := json.Unmarshal(value, &document); err != nil {
+		log.Print(err)
+		return nil
+	}
+	return &document
+}
+
+func putDocument[T any](tx kvTx, bucket string, key string, document *T) error {
+	value, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return tx.put(bucket, key, value)
+}
+
+func allDocuments[T any](tx kvTx, bucket string) ([]*T, error) {
+	documents := []*T{}
+	err := tx.forEach(bucket, func(key string, value []byte) error {
+		var document T
+		if err := json.Unmarshal(value, &document); err != nil {
+			return err
+		}
+		documents = append(documents, &document)
+		return nil
+	})
+	return documents, err
+}
+
+func (s *KVStore) SaveIngredientWithID(input *model.Ingredient) *model.Ingredient {
+	if _, err := primitive.ObjectIDFromHex(input.ID); err != nil {
+		log.Print(err)
+		return nil
+	}
+	ingredient := new(model.Ingredient)
+	*ingredient = *input
+	err := s.update(func(tx kvTx) error {
+		if tx.get(ingredientBucket, ingredient.ID) != nil {
+			return errDuplicateKey
+		}
+		return putDocument(tx, ingredientBucket, ingredient.ID, ingredient)
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return ingredient
+}
+
+func (s *KVStore) SaveIngredient(input *model.IngredientWithoutID) *model.Ingredient {
+	ingredient := input.WithID(primitive.NewObjectID().Hex())
+	err := s.update(func(tx kvTx) error {
+		return putDocument(tx, ingredientBucket, ingredient.ID, ingredient)
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return ingredient
+}
+
+func (s *KVStore) SaveRecipe(input *model.RecipeWithoutID) *model.Recipe {
+	input.Version = 1
+	recipe := recipeFromInput(primitive.NewObjectID().Hex(), input)
+	err := s.update(func(tx kvTx) error {
+		return putDocument(tx, recipeBucket, recipe.ID, recipe)
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return recipe
+}
+
+func (s *KVStore) AllRecipes() []*model.Recipe {
+	var recipes []*model.Recipe
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		recipes, err = allDocuments[model.Recipe](tx, recipeBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return recipes
+}
+
+func (s *KVStore) FindRecipesByCategory(category model.Category) []*model.Recipe {
+	var recipes []*model.Recipe
+	for _, recipe := range s.AllRecipes() {
+		if recipe.Category == category {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes
+}
+
+func (s *KVStore) FindRecipeByID(ID string) *model.ResolvedRecipe {
+	var resolved *model.ResolvedRecipe
+	s.backend.view(func(tx kvTx) error {
+		recipe := getDocument[model.Recipe](tx, recipeBucket, ID)
+		if recipe != nil {
+			resolved = resolveRecipe(tx, recipe)
+		}
+		return nil
+	})
+	return resolved
+}
+
+// resolveRecipe looks up the ingredient of every line, skipping lines whose
+// ingredient no longer exists like the Mongo backend does. The classification
+// counts the skipped lines as unresolved.
+func resolveRecipe(tx kvTx, recipe *model.Recipe) *model.ResolvedRecipe {
+	lines := make([]model.ResolvedIngredientLine, 0, len(recipe.IngredientLines))
+	unresolved := 0
+	for _, line := range recipe.IngredientLines {
+		if ingredient := getDocument[model.Ingredient](tx, ingredientBucket, line.Ingredient.Hex()); ingredient != nil {
+			lines = append(lines, line.Resolve(*ingredient))
+		} else {
+			unresolved++
+		}
+	}
+	return &model.ResolvedRecipe{
+		ID:              recipe.ID,
+		Name:            recipe.Name,
+		Description:     recipe.Description,
+		Category:        recipe.Category,
+		Servings:        recipe.Servings,
+		OwnerID:         recipe.OwnerID,
+		Steps:           recipe.Steps,
+		IngredientLines: lines,
+		Version:         recipe.Version,
+		Classification:  model.ClassifyRecipe(lines, unresolved),
+	}
+}
+
+func (s *KVStore) FindRecipeDocumentByID(ID string) *model.Recipe {
+	var recipe *model.Recipe
+	s.backend.view(func(tx kvTx) error {
+		recipe = getDocument[model.Recipe](tx, recipeBucket, ID)
+		return nil
+	})
+	return recipe
+}
+
+func (s *KVStore) FindRecipeByName(name string) *model.Recipe {
+	for _, recipe := range s.AllRecipes() {
+		if recipe.Name == name {
+			return recipe
+		}
+	}
+	return nil
+}
+
+func (s *KVStore) UpdateRecipe(ID string, version int, input *model.RecipeWithoutID) (*model.ResolvedRecipe, error) {
+	var resolved *model.ResolvedRecipe
+	err := s.update(func(tx kvTx) error {
+		existing := getDocument[model.Recipe](tx, recipeBucket, ID)
+		if existing == nil {
+			return ErrNotFound
+		}
+		if existing.Version != version {
+			return ErrVersionMismatch
+		}
+		input.Version = version + 1
+		recipe := recipeFromInput(ID, input)
+		if err := putDocument(tx, recipeBucket, ID, recipe); err != nil {
+			return err
+		}
+		resolved = resolveRecipe(tx, recipe)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+func (s *KVStore) DeleteRecipe(ID string, version int) (bool, error) {
+	deleted := false
+	err := s.update(func(tx kvTx) error {
+		recipe := getDocument[model.Recipe](tx, recipeBucket, ID)
+		if recipe == nil {
+			return nil
+		}
+		if recipe.Version != version {
+			return ErrVersionMismatch
+		}
+		trashed := &model.TrashedRecipe{
+			ID:              recipe.ID,
+			Name:            recipe.Name,
+			Description:     recipe.Description,
+			Category:        recipe.Category,
+			Servings:        recipe.Servings,
+			OwnerID:         recipe.OwnerID,
+			Version:         recipe.Version,
+			Steps:           recipe.Steps,
+			IngredientLines: recipe.IngredientLines,
+			DeletedAt:       time.Now().UTC(),
+		}
+		if err := putDocument(tx, trashBucket, ID, trashed); err != nil {
+			return err
+		}
+		deleted = true
+		return tx.delete(recipeBucket, ID)
+	})
+	return deleted, err
+}
+
+func (s *KVStore) AllTrashedRecipes() []*model.TrashedRecipe {
+	var recipes []*model.TrashedRecipe
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		recipes, err = allDocuments[model.TrashedRecipe](tx, trashBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return recipes
+}
+
+func (s *KVStore) FindTrashedRecipe(ID string) (*model.TrashedRecipe, error) {
+	var recipe *model.TrashedRecipe
+	err := s.backend.view(func(tx kvTx) error {
+		recipe = getDocument[model.TrashedRecipe](tx, trashBucket, ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if recipe == nil {
+		return nil, ErrNotFound
+	}
+	return recipe, nil
+}
+
+func (s *KVStore) RestoreRecipe(ID string) (bool, error) {
+	restored := false
+	err := s.update(func(tx kvTx) error {
+		trashed := getDocument[model.TrashedRecipe](tx, trashBucket, ID)
+		if trashed == nil {
+			return nil
+		}
+		if err := putDocument(tx, recipeBucket, ID, recipeFromTrashed(trashed)); err != nil {
+			return err
+		}
+		restored = true
+		return tx.delete(trashBucket, ID)
+	})
+	return restored, err
+}
+
+func (s *KVStore) PurgeTrash(before time.Time) (int64, error) {
+	var purged int64
+	err := s.update(func(tx kvTx) error {
+		trashed, err := allDocuments[model.TrashedRecipe](tx, trashBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range trashed {
+			if recipe.DeletedAt.Before(before) {
+				if err := tx.delete(trashBucket, recipe.ID); err != nil {
+					return err
+				}
+				purged++
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+func (s *KVStore) RecipesUsingIngredient(ID string) ([]*model.Recipe, error) {
+	ingredientID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return nil, nil
+	}
+	var recipes []*model.Recipe
+	err = s.backend.view(func(tx kvTx) error {
+		stored, err := allDocuments[model.Recipe](tx, recipeBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range stored {
+			if model.UsesIngredient(recipe.IngredientLines, ingredientID) {
+				recipes = append(recipes, recipe)
+			}
+		}
+		trashed, err := allDocuments[model.TrashedRecipe](tx, trashBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range trashed {
+			if model.UsesIngredient(recipe.IngredientLines, ingredientID) {
+				recipes = append(recipes, recipeFromTrashed(recipe))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// withoutIngredient drops the lines for ingredientID.
+func withoutIngredient(lines []model.IngredientLine, ingredientID primitive.ObjectID) []model.IngredientLine {
+	kept := make([]model.IngredientLine, 0, len(lines))
+	for _, line := range lines {
+		if line.Ingredient != ingredientID {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+func (s *KVStore) AllIngredients() []*model.Ingredient {
+	var ingredients []*model.Ingredient
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		ingredients, err = allDocuments[model.Ingredient](tx, ingredientBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return ingredients
+}
+
+func (s *KVStore) FindIngredientByID(ID string) *model.Ingredient {
+	var ingredient *model.Ingredient
+	s.backend.view(func(tx kvTx) error {
+		ingredient = getDocument[model.Ingredient](tx, ingredientBucket, ID)
+		return nil
+	})
+	return ingredient
+}
+
+func (s *KVStore) FindIngredientByName(name string) *model.Ingredient {
+	return model.FindIngredientByName(s.AllIngredients(), name)
+}
+
+func (s *KVStore) UpdateIngredient(ID string, input *model.IngredientWithoutID) (*model.Ingredient, error) {
+	ingredient := input.WithID(ID)
+	err := s.update(func(tx kvTx) error {
+		if tx.get(ingredientBucket, ID) == nil {
+			return ErrNotFound
+		}
+		return putDocument(tx, ingredientBucket, ID, ingredient)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+func (s *KVStore) DeleteIngredient(ID string) (bool, error) {
+	deleted := false
+	err := s.update(func(tx kvTx) error {
+		if tx.get(ingredientBucket, ID) == nil {
+			return nil
+		}
+		deleted = true
+		return tx.delete(ingredientBucket, ID)
+	})
+	return deleted, err
+}
+
+func recipeFromInput(ID string, input *model.RecipeWithoutID) *model.Recipe {
+	return &model.Recipe{
+		ID:              ID,
+		Name:            input.Name,
+		Description:     input.Description,
+		Category:        input.Category,
+		Servings:        input.Servings,
+		OwnerID:         input.OwnerID,
+		Version:         input.Version,
+		Steps:           input.Steps,
+		IngredientLines: input.IngredientLines,
+	}
+}
+
+func recipeFromTrashed(recipe *model.TrashedRecipe) *model.Recipe {
+	return &model.Recipe{
+		ID:              recipe.ID,
+		Name:            recipe.Name,
+		Description:     recipe.Description,
+		Category:        recipe.Category,
+		Servings:        recipe.Servings,
+		OwnerID:         recipe.OwnerID,
+		Version:         recipe.Version,
+		Steps:           recipe.Steps,
+		IngredientLines: recipe.IngredientLines,
+	}
+}
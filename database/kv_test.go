@@ -0,0 +1,221 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"rest/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testStores returns empty stores for the key-value backends, by name. The
+// bolt file is removed when the test ends.
+func testStores(t *testing.T) map[string]*KVStore {
+	t.Helper()
+	bolt, err := OpenBolt(filepath.Join(t.TempDir(), "recipes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bolt.backend.(*boltBackend).db.Close() })
+	return map[string]*KVStore{"memory": NewMemoryStore(), "bolt": bolt}
+}
+
+// forEachStore runs test against every key-value backend.
+func forEachStore(t *testing.T, test func(t *testing.T, s *KVStore)) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			test(t, s)
+		})
+	}
+}
+
+// saveIngredient stores an ingredient and returns its ObjectID.
+func saveIngredient(t *testing.T, s *KVStore, name string) primitive.ObjectID {
+	t.Helper()
+	ingredient := s.SaveIngredient(&model.IngredientWithoutID{Name: name})
+	if ingredient == nil {
+		t.Fatalf("saving ingredient %s failed", name)
+	}
+	ID, _ := primitive.ObjectIDFromHex(ingredient.ID)
+	return ID
+}
+
+func recipeUsing(name string, ingredients ...primitive.ObjectID) *model.RecipeWithoutID {
+	recipe := &model.RecipeWithoutID{Name: name, Category: model.CategoryMainCourse}
+	for _, ingredient := range ingredients {
+		recipe.IngredientLines = append(recipe.IngredientLines, model.IngredientLine{
+			Ingredient:     ingredient,
+			IngredientMeta: model.IngredientMeta{Quantity: 100, Unit: "g"},
+		})
+	}
+	return recipe
+}
+
+func TestUpdateRecipeVersion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *KVStore) {
+		recipe := s.SaveRecipe(recipeUsing("Soup"))
+		if recipe.Version != 1 {
+			t.Fatalf("new recipe version = %d, want 1", recipe.Version)
+		}
+		if _, err := s.UpdateRecipe(recipe.ID, 1, recipeUsing("Tomato soup")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.UpdateRecipe(recipe.ID, 1, recipeUsing("Pea soup")); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("update with a stale version = %v, want ErrVersionMismatch", err)
+		}
+		if _, err := s.DeleteRecipe(recipe.ID, 1); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("delete with a stale version = %v, want ErrVersionMismatch", err)
+		}
+		stored := s.FindRecipeDocumentByID(recipe.ID)
+		if stored == nil || stored.Name != "Tomato soup" || stored.Version != 2 {
+			t.Errorf("stored recipe = %+v, want the first update at version 2", stored)
+		}
+		if _, err := s.UpdateRecipe(primitive.NewObjectID().Hex(), 1, recipeUsing("Stew")); !errors.Is(err, ErrNotFound) {
+			t.Errorf("update of a missing recipe = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestDeleteAndRestoreRecipe(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *KVStore) {
+		flour := saveIngredient(t, s, "flour")
+		recipe := s.SaveRecipe(recipeUsing("Bread", flour))
+
+		if deleted, err := s.DeleteRecipe(recipe.ID, recipe.Version); err != nil || !deleted {
+			t.Fatalf("DeleteRecipe = %v, %v", deleted, err)
+		}
+		if s.FindRecipeDocumentByID(recipe.ID) != nil {
+			t.Error("deleted recipe is still found")
+		}
+		trashed, err := s.FindTrashedRecipe(recipe.ID)
+		if err != nil || trashed.Name != "Bread" || len(trashed.IngredientLines) != 1 {
+			t.Errorf("FindTrashedRecipe = %+v, %v, want the deleted recipe", trashed, err)
+		}
+
+		if restored, err := s.RestoreRecipe(recipe.ID); err != nil || !restored {
+			t.Fatalf("RestoreRecipe = %v, %v", restored, err)
+		}
+		restored := s.FindRecipeDocumentByID(recipe.ID)
+		if restored == nil || restored.Version != recipe.Version || len(restored.IngredientLines) != 1 || restored.IngredientLines[0].Ingredient != flour {
+			t.Errorf("restored recipe = %+v, want it as it was deleted", restored)
+		}
+		if _, err := s.FindTrashedRecipe(recipe.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindTrashedRecipe after restoring = %v, want ErrNotFound", err)
+		}
+		if restored, err := s.RestoreRecipe(recipe.ID); err != nil || restored {
+			t.Errorf("restoring twice = %v, %v, want false", restored, err)
+		}
+	})
+}
+
+func TestTakeFromPantry(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *KVStore) {
+		flour := saveIngredient(t, s, "flour")
+		var items []*model.PantryItem
+		for _, quantity := range []float64{500, 300, 200} {
+			item, err := s.SavePantryItem(&model.PantryItemWithoutID{Ingredient: flour, Quantity: quantity, Unit: "g"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			items = append(items, item)
+		}
+		if deleted, err := s.DeletePantryItem(items[1].ID); err != nil || !deleted {
+			t.Fatalf("DeletePantryItem = %v, %v", deleted, err)
+		}
+
+		err := s.TakeFromPantry(map[string]float64{items[0].ID: 200, items[1].ID: 100, items[2].ID: 250})
+		if err != nil {
+			t.Fatalf("TakeFromPantry with a deleted item = %v", err)
+		}
+		if item := s.FindPantryItemByID(items[0].ID); item == nil || item.Quantity != 300 {
+			t.Errorf("first item = %+v, want 300 g left", item)
+		}
+		if item := s.FindPantryItemByID(items[1].ID); item != nil {
+			t.Errorf("deleted item = %+v, want it to stay deleted", item)
+		}
+		if item := s.FindPantryItemByID(items[2].ID); item != nil {
+			t.Errorf("used up item = %+v, want it removed", item)
+		}
+	})
+}
+
+// corruptSubstitutions stores a substitution that cannot be decoded, so that
+// a transaction reading the substitutions fails part way.
+func corruptSubstitutions(t *testing.T, s *KVStore) {
+	t.Helper()
+	err := s.backend.update(func(tx kvTx) error {
+		return tx.put(substitutionBucket, primitive.NewObjectID().Hex(), []byte("{"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeIngredientsRollback(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s *KVStore) {
+		butter := saveIngredient(t, s, "butter")
+		duplicate := saveIngredient(t, s, "buter")
+		recipe := s.SaveRecipe(recipeUsing("Shortbread", duplicate))
+		item, err := s.SavePantryItem(&model.PantryItemWithoutID{Ingredient: duplicate, Quantity: 250, Unit: "g"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.MergeIngredients(butter.Hex(), []string{duplicate.Hex(), primitive.NewObjectID().Hex()}, &model.IngredientWithoutID{Name: "butter"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("merging a missing duplicate = %v, want ErrNotFound", err)
+		}
+		// The recipes and pantry are rewritten before the substitutions
+		// fail to load.
+		corruptSubstitutions(t, s)
+		merged := &model.IngredientWithoutID{Name: "butter", Aliases: []string{"buter"}}
+		if err := s.MergeIngredients(butter.Hex(), []string{duplicate.Hex()}, merged); err == nil {
+			t.Fatal("merge with a broken substitution succeeded")
+		}
+
+		if ingredient := s.FindIngredientByID(butter.Hex()); ingredient == nil || len(ingredient.Aliases) != 0 {
+			t.Errorf("ingredient = %+v, want it unchanged", ingredient)
+		}
+		if s.FindIngredientByID(duplicate.Hex()) == nil {
+			t.Error("duplicate was deleted")
+		}
+		stored := s.FindRecipeDocumentByID(recipe.ID)
+		if stored.Version != recipe.Version || stored.IngredientLines[0].Ingredient != duplicate {
+			t.Errorf("recipe = %+v, want it unchanged", stored)
+		}
+		if stored := s.FindPantryItemByID(item.ID); stored.Ingredient != duplicate {
+			t.Errorf("pantry item = %+v, want it unchanged", stored)
+		}
+
+		if err := s.DeleteIngredientCascade(duplicate.Hex()); err == nil {
+			t.Fatal("cascading delete with a broken substitution succeeded")
+		}
+		if s.FindIngredientByID(duplicate.Hex()) == nil || s.FindPantryItemByID(item.ID) == nil {
+			t.Error("cascading delete was not undone")
+		}
+		if stored := s.FindRecipeDocumentByID(recipe.ID); len(stored.IngredientLines) != 1 || stored.Version != recipe.Version {
+			t.Errorf("recipe = %+v, want it unchanged", stored)
+		}
+	})
+}
+
+func TestBoltKeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recipes.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipe := s.SaveRecipe(recipeUsing("Soup", saveIngredient(t, s, "leek")))
+	s.backend.(*boltBackend).db.Close()
+
+	s, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.backend.(*boltBackend).db.Close()
+	stored := s.FindRecipeByID(recipe.ID)
+	if stored == nil || len(stored.IngredientLines) != 1 || stored.IngredientLines[0].Ingredient.Name != "leek" {
+		t.Errorf("recipe after reopening = %+v, want the soup with its leek", stored)
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveAPIKey(key *model.APIKey) error {
+	key.ID = primitive.NewObjectID().Hex()
+	return s.backend.update(func(tx kvTx) error {
+		return putDocument(tx, apiKeyBucket, key.ID, key)
+	})
+}
+
+func (s *KVStore) AllAPIKeys() []*model.APIKey {
+	var keys []*model.APIKey
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		keys, err = allDocuments[model.APIKey](tx, apiKeyBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return keys
+}
+
+func (s *KVStore) FindAPIKeyByID(ID string) *model.APIKey {
+	var key *model.APIKey
+	s.backend.view(func(tx kvTx) error {
+		key = getDocument[model.APIKey](tx, apiKeyBucket, ID)
+		return nil
+	})
+	return key
+}
+
+// FindAPIKeyByHash scans every key; there are few enough that an index is
+// not worth keeping.
+func (s *KVStore) FindAPIKeyByHash(hash string) *model.APIKey {
+	for _, key := range s.AllAPIKeys() {
+		if key.KeyHash == hash {
+			return key
+		}
+	}
+	return nil
+}
+
+func (s *KVStore) RevokeAPIKey(ID string, at time.Time) error {
+	return s.updateAPIKey(ID, func(key *model.APIKey) {
+		key.RevokedAt = &at
+	})
+}
+
+func (s *KVStore) RecordAPIKeyUse(ID string, at time.Time) error {
+	return s.updateAPIKey(ID, func(key *model.APIKey) {
+		key.Usage++
+		key.LastUsedAt = &at
+	})
+}
+
+func (s *KVStore) updateAPIKey(ID string, change func(key *model.APIKey)) error {
+	return s.backend.update(func(tx kvTx) error {
+		key := getDocument[model.APIKey](tx, apiKeyBucket, ID)
+		if key == nil {
+			return ErrNotFound
+		}
+		change(key)
+		return putDocument(tx, apiKeyBucket, ID, key)
+	})
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveMealPlan(input *model.MealPlanWithoutID) (*model.MealPlan, error) {
+	plan := input.WithID(primitive.NewObjectID().Hex())
+	err := s.backend.update(func(tx kvTx) error {
+		return putDocument(tx, mealPlanBucket, plan.ID, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *KVStore) AllMealPlans() []*model.MealPlan {
+	var plans []*model.MealPlan
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		plans, err = allDocuments[model.MealPlan](tx, mealPlanBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return plans
+}
+
+func (s *KVStore) FindMealPlanByID(ID string) *model.MealPlan {
+	var plan *model.MealPlan
+	s.backend.view(func(tx kvTx) error {
+		plan = getDocument[model.MealPlan](tx, mealPlanBucket, ID)
+		return nil
+	})
+	return plan
+}
+
+func (s *KVStore) UpdateMealPlan(ID string, input *model.MealPlanWithoutID) (*model.MealPlan, error) {
+	plan := input.WithID(ID)
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(mealPlanBucket, ID) == nil {
+			return ErrNotFound
+		}
+		return putDocument(tx, mealPlanBucket, ID, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *KVStore) DeleteMealPlan(ID string) (bool, error) {
+	deleted := false
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(mealPlanBucket, ID) == nil {
+			return nil
+		}
+		deleted = true
+		return tx.delete(mealPlanBucket, ID)
+	})
+	return deleted, err
+}
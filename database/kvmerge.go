@@ -0,0 +1,164 @@
+package database
+
+import (
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MergeIngredients runs in one transaction, so a failure changes nothing.
+func (s *KVStore) MergeIngredients(ID string, duplicates []string, merged *model.IngredientWithoutID) error {
+	canonicalID, duplicateIDs, err := mergeIDs(ID, duplicates)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx kvTx) error {
+		for _, key := range append([]string{ID}, duplicates...) {
+			if tx.get(ingredientBucket, key) == nil {
+				return ErrNotFound
+			}
+		}
+		if err := putDocument(tx, ingredientBucket, ID, merged.WithID(ID)); err != nil {
+			return err
+		}
+
+		recipes, err := allDocuments[model.Recipe](tx, recipeBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range recipes {
+			lines, changed := model.ReplaceIngredients(recipe.IngredientLines, duplicateIDs, canonicalID)
+			if !changed {
+				continue
+			}
+			recipe.IngredientLines = lines
+			recipe.Version++
+			if err := putDocument(tx, recipeBucket, recipe.ID, recipe); err != nil {
+				return err
+			}
+		}
+		trashed, err := allDocuments[model.TrashedRecipe](tx, trashBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range trashed {
+			lines, changed := model.ReplaceIngredients(recipe.IngredientLines, duplicateIDs, canonicalID)
+			if !changed {
+				continue
+			}
+			recipe.IngredientLines = lines
+			recipe.Version++
+			if err := putDocument(tx, trashBucket, recipe.ID, recipe); err != nil {
+				return err
+			}
+		}
+
+		items, err := allDocuments[model.PantryItem](tx, pantryBucket)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if !duplicateIDs[item.Ingredient] {
+				continue
+			}
+			item.Ingredient = canonicalID
+			if err := putDocument(tx, pantryBucket, item.ID, item); err != nil {
+				return err
+			}
+		}
+
+		substitutions, err := allDocuments[model.Substitution](tx, substitutionBucket)
+		if err != nil {
+			return err
+		}
+		for _, substitution := range substitutions {
+			changed, keep := substitution.ReplaceIngredients(duplicateIDs, canonicalID)
+			if !keep {
+				err = tx.delete(substitutionBucket, substitution.ID)
+			} else if changed {
+				err = putDocument(tx, substitutionBucket, substitution.ID, substitution)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, duplicate := range duplicates {
+			if err := tx.delete(ingredientBucket, duplicate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteIngredientCascade runs in one transaction, so a failure changes
+// nothing.
+func (s *KVStore) DeleteIngredientCascade(ID string) error {
+	ingredientID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	return s.update(func(tx kvTx) error {
+		if tx.get(ingredientBucket, ID) == nil {
+			return ErrNotFound
+		}
+
+		recipes, err := allDocuments[model.Recipe](tx, recipeBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range recipes {
+			if !model.UsesIngredient(recipe.IngredientLines, ingredientID) {
+				continue
+			}
+			recipe.IngredientLines = withoutIngredient(recipe.IngredientLines, ingredientID)
+			recipe.Version++
+			if err := putDocument(tx, recipeBucket, recipe.ID, recipe); err != nil {
+				return err
+			}
+		}
+		trashed, err := allDocuments[model.TrashedRecipe](tx, trashBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range trashed {
+			if !model.UsesIngredient(recipe.IngredientLines, ingredientID) {
+				continue
+			}
+			recipe.IngredientLines = withoutIngredient(recipe.IngredientLines, ingredientID)
+			recipe.Version++
+			if err := putDocument(tx, trashBucket, recipe.ID, recipe); err != nil {
+				return err
+			}
+		}
+
+		items, err := allDocuments[model.PantryItem](tx, pantryBucket)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.Ingredient != ingredientID {
+				continue
+			}
+			if err := tx.delete(pantryBucket, item.ID); err != nil {
+				return err
+			}
+		}
+
+		substitutions, err := allDocuments[model.Substitution](tx, substitutionBucket)
+		if err != nil {
+			return err
+		}
+		for _, substitution := range substitutions {
+			if !substitution.UsesIngredient(ingredientID) {
+				continue
+			}
+			if err := tx.delete(substitutionBucket, substitution.ID); err != nil {
+				return err
+			}
+		}
+
+		return tx.delete(ingredientBucket, ID)
+	})
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SavePantryItem(input *model.PantryItemWithoutID) (*model.PantryItem, error) {
+	item := input.WithID(primitive.NewObjectID().Hex())
+	err := s.backend.update(func(tx kvTx) error {
+		return putDocument(tx, pantryBucket, item.ID, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *KVStore) AllPantryItems() []*model.PantryItem {
+	var items []*model.PantryItem
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		items, err = allDocuments[model.PantryItem](tx, pantryBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return items
+}
+
+func (s *KVStore) FindPantryItemByID(ID string) *model.PantryItem {
+	var item *model.PantryItem
+	s.backend.view(func(tx kvTx) error {
+		item = getDocument[model.PantryItem](tx, pantryBucket, ID)
+		return nil
+	})
+	return item
+}
+
+func (s *KVStore) UpdatePantryItem(ID string, input *model.PantryItemWithoutID) (*model.PantryItem, error) {
+	item := input.WithID(ID)
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(pantryBucket, ID) == nil {
+			return ErrNotFound
+		}
+		return putDocument(tx, pantryBucket, ID, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *KVStore) DeletePantryItem(ID string) (bool, error) {
+	deleted := false
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(pantryBucket, ID) == nil {
+			return nil
+		}
+		deleted = true
+		return tx.delete(pantryBucket, ID)
+	})
+	return deleted, err
+}
+
+func (s *KVStore) PantryItemsUsingIngredient(ID string) ([]*model.PantryItem, error) {
+	ingredientID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return []*model.PantryItem{}, nil
+	}
+	items := []*model.PantryItem{}
+	err = s.backend.view(func(tx kvTx) error {
+		stored, err := allDocuments[model.PantryItem](tx, pantryBucket)
+		for _, item := range stored {
+			if item.Ingredient == ingredientID {
+				items = append(items, item)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// TakeFromPantry reads and writes the items in one transaction, so a
+// concurrent change to the pantry is not overwritten.
+func (s *KVStore) TakeFromPantry(amounts map[string]float64) error {
+	return s.backend.update(func(tx kvTx) error {
+		for ID, amount := range amounts {
+			item := getDocument[model.PantryItem](tx, pantryBucket, ID)
+			if item == nil {
+				continue
+			}
+			item.Quantity -= amount
+			if item.Quantity < usedUp {
+				if err := tx.delete(pantryBucket, ID); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := putDocument(tx, pantryBucket, ID, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"rest/model"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveRevision(revision *model.RecipeRevision) error {
+	return s.backend.update(func(tx kvTx) error {
+		revisions, err := recipeRevisions(tx, revision.RecipeID)
+		if err != nil {
+			return err
+		}
+		revision.Number = len(revisions) + 1
+		revision.ID = primitive.NewObjectID().Hex()
+		return putDocument(tx, revisionBucket, revision.ID, revision)
+	})
+}
+
+func (s *KVStore) RecipeRevisions(recipeID string) ([]*model.RecipeRevision, error) {
+	var revisions []*model.RecipeRevision
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		revisions, err = recipeRevisions(tx, recipeID)
+		return err
+	})
+	return revisions, err
+}
+
+func (s *KVStore) FindRevision(recipeID string, number int) *model.RecipeRevision {
+	revisions, err := s.RecipeRevisions(recipeID)
+	if err != nil || number < 1 || number > len(revisions) {
+		return nil
+	}
+	return revisions[number-1]
+}
+
+func recipeRevisions(tx kvTx, recipeID string) ([]*model.RecipeRevision, error) {
+	all, err := allDocuments[model.RecipeRevision](tx, revisionBucket)
+	if err != nil {
+		return nil, err
+	}
+	revisions := []*model.RecipeRevision{}
+	for _, revision := range all {
+		if revision.RecipeID == recipeID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Number < revisions[j].Number })
+	return revisions, nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+	"rest/search"
+)
+
+// Field weights for full-text search. The Mongo text index uses the same
+// weights.
+const (
+	searchWeightName        = 10
+	searchWeightIngredient  = 5
+	searchWeightDescription = 2
+	searchWeightSteps       = 1
+)
+
+func (s *KVStore) SearchRecipes(query string, category model.Category, limit int) []*model.RecipeSearchHit {
+	index := s.recipeSearchIndex()
+	if index == nil {
+		return nil
+	}
+	hits := []*model.RecipeSearchHit{}
+	for _, hit := range index.Search(query) {
+		if len(hits) >= limit {
+			break
+		}
+		recipe := s.FindRecipeByID(hit.ID)
+		if recipe == nil || (category != "" && recipe.Category != category) {
+			continue
+		}
+		hits = append(hits, &model.RecipeSearchHit{Recipe: *recipe, Score: hit.Score})
+	}
+	return hits
+}
+
+func (s *KVStore) recipeSearchIndex() *search.Index {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	if s.searchIndex != nil {
+		return s.searchIndex
+	}
+
+	index := search.NewIndex()
+	err := s.backend.view(func(tx kvTx) error {
+		recipes, err := allDocuments[model.Recipe](tx, recipeBucket)
+		if err != nil {
+			return err
+		}
+		for _, recipe := range recipes {
+			index.Add(recipe.ID, recipeSearchFields(resolveRecipe(tx, recipe)))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	s.searchIndex = index
+	return index
+}
+
+func (s *KVStore) invalidateSearchIndex() {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	s.searchIndex = nil
+}
+
+func recipeSearchFields(recipe *model.ResolvedRecipe) []search.Field {
+	fields := []search.Field{
+		{Name: "name", Text: recipe.Name, Weight: searchWeightName},
+		{Name: "description", Text: recipe.Description, Weight: searchWeightDescription},
+	}
+	for _, step := range recipe.Steps {
+		fields = append(fields, search.Field{Name: "steps", Text: step, Weight: searchWeightSteps})
+	}
+	seen := map[string]bool{}
+	for _, line := range recipe.IngredientLines {
+		if seen[line.Ingredient.ID] {
+			continue
+		}
+		seen[line.Ingredient.ID] = true
+		fields = append(fields, search.Field{Name: "ingredients", Text: line.Ingredient.Name, Weight: searchWeightIngredient})
+	}
+	return fields
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveShoppingList(list *model.ShoppingList) error {
+	list.ID = primitive.NewObjectID().Hex()
+	return s.backend.update(func(tx kvTx) error {
+		return putDocument(tx, shoppingBucket, list.ID, list)
+	})
+}
+
+func (s *KVStore) AllShoppingLists() []*model.ShoppingList {
+	var lists []*model.ShoppingList
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		lists, err = allDocuments[model.ShoppingList](tx, shoppingBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return lists
+}
+
+func (s *KVStore) FindShoppingListByID(ID string) *model.ShoppingList {
+	var list *model.ShoppingList
+	s.backend.view(func(tx kvTx) error {
+		list = getDocument[model.ShoppingList](tx, shoppingBucket, ID)
+		return nil
+	})
+	return list
+}
+
+func (s *KVStore) CheckShoppingListItem(ID string, itemID int, checked bool) (*model.ShoppingList, error) {
+	var list *model.ShoppingList
+	err := s.backend.update(func(tx kvTx) error {
+		list = getDocument[model.ShoppingList](tx, shoppingBucket, ID)
+		if list == nil || !list.SetChecked(itemID, checked) {
+			return ErrNotFound
+		}
+		return putDocument(tx, shoppingBucket, ID, list)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *KVStore) DeleteShoppingList(ID string) (bool, error) {
+	deleted := false
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(shoppingBucket, ID) == nil {
+			return nil
+		}
+		deleted = true
+		return tx.delete(shoppingBucket, ID)
+	})
+	return deleted, err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveSubstitution(input *model.SubstitutionWithoutID) (*model.Substitution, error) {
+	substitution := input.WithID(primitive.NewObjectID().Hex())
+	err := s.backend.update(func(tx kvTx) error {
+		return putDocument(tx, substitutionBucket, substitution.ID, substitution)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return substitution, nil
+}
+
+func (s *KVStore) AllSubstitutions() []*model.Substitution {
+	var substitutions []*model.Substitution
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		substitutions, err = allDocuments[model.Substitution](tx, substitutionBucket)
+		return err
+	})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return substitutions
+}
+
+func (s *KVStore) FindSubstitutionByID(ID string) *model.Substitution {
+	var substitution *model.Substitution
+	s.backend.view(func(tx kvTx) error {
+		substitution = getDocument[model.Substitution](tx, substitutionBucket, ID)
+		return nil
+	})
+	return substitution
+}
+
+func (s *KVStore) DeleteSubstitution(ID string) (bool, error) {
+	deleted := false
+	err := s.backend.update(func(tx kvTx) error {
+		if tx.get(substitutionBucket, ID) == nil {
+			return nil
+		}
+		deleted = true
+		return tx.delete(substitutionBucket, ID)
+	})
+	return deleted, err
+}
+
+func (s *KVStore) SubstitutionsUsingIngredient(ID string) ([]*model.Substitution, error) {
+	ingredientID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return []*model.Substitution{}, nil
+	}
+	substitutions := []*model.Substitution{}
+	err = s.backend.view(func(tx kvTx) error {
+		stored, err := allDocuments[model.Substitution](tx, substitutionBucket)
+		for _, substitution := range stored {
+			if substitution.UsesIngredient(ingredientID) {
+				substitutions = append(substitutions, substitution)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return substitutions, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *KVStore) SaveUser(user *model.User) error {
+	user.ID = primitive.NewObjectID().Hex()
+	return s.backend.update(func(tx kvTx) error {
+		users, err := allDocuments[model.User](tx, userBucket)
+		if err != nil {
+			return err
+		}
+		for _, existing := range users {
+			if existing.Username == user.Username {
+				return ErrUsernameTaken
+			}
+		}
+		if len(users) == 0 {
+			user.Role = model.RoleAdmin
+		}
+		return putDocument(tx, userBucket, user.ID, user)
+	})
+}
+
+func (s *KVStore) FindUserByID(ID string) *model.User {
+	var user *model.User
+	s.backend.view(func(tx kvTx) error {
+		user = getDocument[model.User](tx, userBucket, ID)
+		return nil
+	})
+	return user
+}
+
+func (s *KVStore) FindUserByUsername(username string) *model.User {
+	users, err := s.allUsers()
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	for _, user := range users {
+		if user.Username == username {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *KVStore) CountUsers() (int64, error) {
+	users, err := s.allUsers()
+	return int64(len(users)), err
+}
+
+func (s *KVStore) AllUsers() []*model.User {
+	users, err := s.allUsers()
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return users
+}
+
+func (s *KVStore) SetUserRole(ID string, role model.Role) error {
+	return s.backend.update(func(tx kvTx) error {
+		user := getDocument[model.User](tx, userBucket, ID)
+		if user == nil {
+			return ErrNotFound
+		}
+		user.Role = role
+		return putDocument(tx, userBucket, ID, user)
+	})
+}
+
+func (s *KVStore) allUsers() ([]*model.User, error) {
+	var users []*model.User
+	err := s.backend.view(func(tx kvTx) error {
+		var err error
+		users, err = allDocuments[model.User](tx, userBucket)
+		return err
+	})
+	return users, err
+}
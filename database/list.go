@@ -0,0 +1,83 @@
+package database
+
+import (
+	"rest/model"
+	"slices"
+	"sort"
+)
+
+// sortKey is what a list is ordered by: the name when sorting by name, and
+// always the ID, which is also the creation order.
+type sortKey struct {
+	Name string
+	ID   string
+}
+
+func (k sortKey) less(other sortKey, field model.SortField) bool {
+	if field == model.SortByName && k.Name != other.Name {
+		return k.Name < other.Name
+	}
+	return k.ID < other.ID
+}
+
+// page sorts items, skips everything up to and including query.After and
+// returns at most query.Limit items and whether more follow. It implements
+// ListQuery for backends that load whole collections.
+func page[T any](items []T, key func(T) sortKey, query model.ListQuery) ([]T, bool) {
+	before := func(a sortKey, b sortKey) bool {
+		if query.Descending {
+			return b.less(a, query.SortField)
+		}
+		return a.less(b, query.SortField)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return before(key(items[i]), key(items[j]))
+	})
+	if query.After != nil {
+		after := sortKey{Name: query.After.Name, ID: query.After.ID}
+		start := sort.Search(len(items), func(i int) bool {
+			return before(after, key(items[i]))
+		})
+		items = items[start:]
+	}
+	if len(items) > query.Limit {
+		return items[:query.Limit], true
+	}
+	return items, false
+}
+
+func (s *KVStore) ListRecipes(query model.ListQuery) ([]*model.Recipe, bool, error) {
+	var recipes []*model.Recipe
+	if query.Category != "" {
+		recipes = s.FindRecipesByCategory(query.Category)
+	} else if recipes = s.AllRecipes(); recipes == nil {
+		return nil, false, errLoadFailed
+	}
+	if query.Ingredient != nil {
+		recipes = slices.DeleteFunc(recipes, func(recipe *model.Recipe) bool {
+			return !model.UsesIngredient(recipe.IngredientLines, *query.Ingredient)
+		})
+	}
+	if query.AllowedIngredients != nil {
+		recipes = slices.DeleteFunc(recipes, func(recipe *model.Recipe) bool {
+			return slices.ContainsFunc(recipe.IngredientLines, func(line model.IngredientLine) bool {
+				return !slices.Contains(query.AllowedIngredients, line.Ingredient)
+			})
+		})
+	}
+	recipes, more := page(recipes, func(recipe *model.Recipe) sortKey {
+		return sortKey{Name: recipe.Name, ID: recipe.ID}
+	}, query)
+	return recipes, more, nil
+}
+
+func (s *KVStore) ListIngredients(query model.ListQuery) ([]*model.Ingredient, bool, error) {
+	ingredients := s.AllIngredients()
+	if ingredients == nil {
+		return nil, false, errLoadFailed
+	}
+	ingredients, more := page(ingredients, func(ingredient *model.Ingredient) sortKey {
+		return sortKey{Name: ingredient.Name, ID: ingredient.ID}
+	}, query)
+	return ingredients, more, nil
+}
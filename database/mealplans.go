@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (db *DB) SaveMealPlan(input *model.MealPlanWithoutID) (*model.MealPlan, error) {
+	plan := input.WithID(primitive.NewObjectID().Hex())
+	if err := insertDocument(db.mealPlanCollection, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (db *DB) AllMealPlans() []*model.MealPlan {
+	plans, err := findDocuments[model.MealPlan](db.mealPlanCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return plans
+}
+
+func (db *DB) FindMealPlanByID(ID string) *model.MealPlan {
+	plan, err := findDocument[model.MealPlan](db.mealPlanCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return plan
+}
+
+func (db *DB) UpdateMealPlan(ID string, input *model.MealPlanWithoutID) (*model.MealPlan, error) {
+	if _, err := primitive.ObjectIDFromHex(ID); err != nil {
+		return nil, ErrNotFound
+	}
+	plan := input.WithID(ID)
+	if err := replaceDocument(db.mealPlanCollection, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (db *DB) DeleteMealPlan(ID string) (bool, error) {
+	return deleteDocument(db.mealPlanCollection, ID)
+}
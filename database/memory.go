@@ -0,0 +1,102 @@
+package database
+
+import (
+	"sort"
+	"sync"
+)
+
+// NewMemoryStore returns a Store that keeps all data in process memory. It is
+// meant for local development and tests, and loses everything when the
+// process exits.
+func NewMemoryStore() *KVStore {
+	return &KVStore{backend: &memoryBackend{buckets: map[string]map[string][]byte{}}}
+}
+
+type memoryBackend struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+func (b *memoryBackend) view(fn func(tx kvTx) error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return fn(&memoryTx{backend: b})
+}
+
+// update runs fn against a set of pending writes that are only applied to
+// the buckets if fn succeeds, so a failed update leaves no partial changes.
+func (b *memoryBackend) update(fn func(tx kvTx) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tx := &memoryTx{backend: b, pending: map[string]map[string][]byte{}}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	for bucket, writes := range tx.pending {
+		if b.buckets[bucket] == nil {
+			b.buckets[bucket] = map[string][]byte{}
+		}
+		for key, value := range writes {
+			if value == nil {
+				delete(b.buckets[bucket], key)
+			} else {
+				b.buckets[bucket][key] = value
+			}
+		}
+	}
+	return nil
+}
+
+// memoryTx reads through its pending writes to the committed buckets. A nil
+// pending value marks a deleted key.
+type memoryTx struct {
+	backend *memoryBackend
+	pending map[string]map[string][]byte
+}
+
+func (tx *memoryTx) get(bucket string, key string) []byte {
+	if value, ok := tx.pending[bucket][key]; ok {
+		return value
+	}
+	return tx.backend.buckets[bucket][key]
+}
+
+func (tx *memoryTx) put(bucket string, key string, value []byte) error {
+	if tx.pending == nil {
+		return errReadOnly
+	}
+	if tx.pending[bucket] == nil {
+		tx.pending[bucket] = map[string][]byte{}
+	}
+	tx.pending[bucket][key] = value
+	return nil
+}
+
+func (tx *memoryTx) delete(bucket string, key string) error {
+	return tx.put(bucket, key, nil)
+}
+
+func (tx *memoryTx) forEach(bucket string, fn func(key string, value []byte) error) error {
+	keys := map[string]bool{}
+	for key := range tx.backend.buckets[bucket] {
+		keys[key] = true
+	}
+	for key := range tx.pending[bucket] {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		value := tx.get(bucket, key)
+		if value == nil {
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MergeIngredients writes one document at a time, since Mongo does not give
+// us a transaction without a replica set. Every document is saved before it
+// is changed, and if a step fails the saved copies are written back, as far
+// as nobody changed them since.
+func (db *DB) MergeIngredients(ID string, duplicates []string, merged *model.IngredientWithoutID) error {
+	canonicalID, duplicateIDs, err := mergeIDs(ID, duplicates)
+	if err != nil {
+		return err
+	}
+	return undoing(func(ctx context.Context, undo *undoLog) error {
+		return db.mergeIngredients(ctx, undo, ID, canonicalID, duplicates, duplicateIDs, merged)
+	})
+}
+
+func (db *DB) mergeIngredients(ctx context.Context, undo *undoLog, ID string, canonicalID primitive.ObjectID, duplicates []string, duplicateIDs map[primitive.ObjectID]bool, merged *model.IngredientWithoutID) error {
+	canonical, err := findDocument[model.Ingredient](db.ingredientCollection, ID)
+	if err != nil {
+		return err
+	}
+	var removed []*model.Ingredient
+	for _, duplicate := range duplicates {
+		ingredient, err := findDocument[model.Ingredient](db.ingredientCollection, duplicate)
+		if err != nil {
+			return err
+		}
+		removed = append(removed, ingredient)
+	}
+
+	if err := replaceDocument(db.ingredientCollection, merged.WithID(ID)); err != nil {
+		return err
+	}
+	undo.add(db.ingredientCollection, canonical, nil)
+
+	inDuplicates := make(bson.A, 0, len(duplicateIDs))
+	for duplicateID := range duplicateIDs {
+		inDuplicates = append(inDuplicates, duplicateID)
+	}
+	for _, collection := range []*mongo.Collection{db.recipeCollection, db.trashCollection} {
+		cur, err := collection.Find(ctx, bson.M{"ingredientlines.ingredient": bson.M{"$in": inDuplicates}})
+		if err != nil {
+			return err
+		}
+		var recipes []bson.M
+		if err := cur.All(ctx, &recipes); err != nil {
+			return err
+		}
+		for _, stored := range recipes {
+			var recipe model.Recipe
+			data, _ := bson.Marshal(stored)
+			if err := bson.Unmarshal(data, &recipe); err != nil {
+				return err
+			}
+			lines, _ := model.ReplaceIngredients(recipe.IngredientLines, duplicateIDs, canonicalID)
+			// The recipe is only changed if it is still the version that
+			// was read, so a concurrent edit is not overwritten.
+			filter := bson.M{"_id": stored["_id"], "version": versionFilter(recipe.Version)}
+			res, err := collection.UpdateOne(ctx, filter, bson.M{
+				"$set": bson.M{"ingredientlines": lines},
+				"$inc": bson.M{"version": 1},
+			})
+			if err != nil {
+				return err
+			}
+			if res.MatchedCount == 0 {
+				return fmt.Errorf("recipe %s: %w", recipe.ID, ErrVersionMismatch)
+			}
+			undo.add(collection, stored, bson.M{"_id": stored["_id"], "version": recipe.Version + 1})
+		}
+	}
+
+	items, err := findDocuments[model.PantryItem](db.pantryCollection, bson.M{"ingredient": bson.M{"$in": inDuplicates}})
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		changed := *item
+		changed.Ingredient = canonicalID
+		if err := replaceDocument(db.pantryCollection, &changed); err != nil {
+			return err
+		}
+		undo.add(db.pantryCollection, item, nil)
+	}
+
+	substitutions, err := findDocuments[model.Substitution](db.substitutionCollection, bson.M{"$or": bson.A{
+		bson.M{"ingredient": bson.M{"$in": inDuplicates}},
+		bson.M{"replacements.ingredient": bson.M{"$in": inDuplicates}},
+	}})
+	if err != nil {
+		return err
+	}
+	for _, substitution := range substitutions {
+		changed := *substitution
+		if _, keep := changed.ReplaceIngredients(duplicateIDs, canonicalID); keep {
+			err = replaceDocument(db.substitutionCollection, &changed)
+		} else {
+			_, err = deleteDocument(db.substitutionCollection, substitution.ID)
+		}
+		if err != nil {
+			return err
+		}
+		undo.add(db.substitutionCollection, substitution, nil)
+	}
+
+	for _, ingredient := range removed {
+		if _, err := deleteDocument(db.ingredientCollection, ingredient.ID); err != nil {
+			return err
+		}
+		undo.add(db.ingredientCollection, ingredient, nil)
+	}
+	return nil
+}
+
+// DeleteIngredientCascade writes one document at a time and undoes the
+// earlier steps if a later one fails, like MergeIngredients.
+func (db *DB) DeleteIngredientCascade(ID string) error {
+	ingredientID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	return undoing(func(ctx context.Context, undo *undoLog) error {
+		ingredient, err := findDocument[model.Ingredient](db.ingredientCollection, ID)
+		if err != nil {
+			return err
+		}
+
+		for _, collection := range []*mongo.Collection{db.recipeCollection, db.trashCollection} {
+			cur, err := collection.Find(ctx, bson.M{"ingredientlines.ingredient": ingredientID})
+			if err != nil {
+				return err
+			}
+			var recipes []bson.M
+			if err := cur.All(ctx, &recipes); err != nil {
+				return err
+			}
+			for _, stored := range recipes {
+				var recipe model.Recipe
+				data, _ := bson.Marshal(stored)
+				if err := bson.Unmarshal(data, &recipe); err != nil {
+					return err
+				}
+				filter := bson.M{"_id": stored["_id"], "version": versionFilter(recipe.Version)}
+				res, err := collection.UpdateOne(ctx, filter, bson.M{
+					"$set": bson.M{"ingredientlines": withoutIngredient(recipe.IngredientLines, ingredientID)},
+					"$inc": bson.M{"version": 1},
+				})
+				if err != nil {
+					return err
+				}
+				if res.MatchedCount == 0 {
+					return fmt.Errorf("recipe %s: %w", recipe.ID, ErrVersionMismatch)
+				}
+				undo.add(collection, stored, bson.M{"_id": stored["_id"], "version": recipe.Version + 1})
+			}
+		}
+
+		items, err := findDocuments[model.PantryItem](db.pantryCollection, bson.M{"ingredient": ingredientID})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if _, err := deleteDocument(db.pantryCollection, item.ID); err != nil {
+				return err
+			}
+			undo.add(db.pantryCollection, item, nil)
+		}
+
+		substitutions, err := findDocuments[model.Substitution](db.substitutionCollection, usingIngredientFilter(ingredientID))
+		if err != nil {
+			return err
+		}
+		for _, substitution := range substitutions {
+			if _, err := deleteDocument(db.substitutionCollection, substitution.ID); err != nil {
+				return err
+			}
+			undo.add(db.substitutionCollection, substitution, nil)
+		}
+
+		if _, err := deleteDocument(db.ingredientCollection, ID); err != nil {
+			return err
+		}
+		undo.add(db.ingredientCollection, ingredient, nil)
+		return nil
+	})
+}
+
+// undoing runs fn, which changes several documents, with an empty undo log.
+// If fn fails, the steps it logged are undone.
+func undoing(fn func(ctx context.Context, undo *undoLog) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	undo := &undoLog{}
+	err := fn(ctx, undo)
+	if err != nil {
+		// The steps are undone with a fresh context, in case fn failed
+		// because ctx ran out.
+		undoCtx, cancelUndo := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancelUndo()
+		if undoErr := undo.run(undoCtx); undoErr != nil {
+			log.Print("could not undo changes: ", undoErr)
+			return fmt.Errorf("%w, and undoing it failed: %v", err, undoErr)
+		}
+	}
+	return err
+}
+
+// undoLog writes back the documents that a change to several documents, like
+// an ingredient merge, changed, latest change first.
+type undoLog struct {
+	steps []undoStep
+}
+
+type undoStep struct {
+	collection *mongo.Collection
+	document   any
+	// filter matches the document as the change left it. It is nil to
+	// match on the ID alone.
+	filter bson.M
+}
+
+func (u *undoLog) add(collection *mongo.Collection, document any, filter bson.M) {
+	u.steps = append(u.steps, undoStep{collection: collection, document: document, filter: filter})
+}
+
+func (u *undoLog) run(ctx context.Context) error {
+	for i := len(u.steps) - 1; i >= 0; i-- {
+		step := u.steps[i]
+		stored, ok := step.document.(bson.M)
+		if !ok {
+			var err error
+			if stored, err = withObjectID(step.document); err != nil {
+				return err
+			}
+		}
+		filter := step.filter
+		if filter == nil {
+			filter = bson.M{"_id": stored["_id"]}
+		}
+		// Upserting puts back deleted documents too.
+		_, err := step.collection.ReplaceOne(ctx, filter, stored, options.Replace().SetUpsert(step.filter == nil))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeIDs parses the IDs of an ingredient and the duplicates to merge into
+// it.
+func mergeIDs(ID string, duplicates []string) (primitive.ObjectID, map[primitive.ObjectID]bool, error) {
+	canonicalID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return canonicalID, nil, ErrNotFound
+	}
+	duplicateIDs := make(map[primitive.ObjectID]bool, len(duplicates))
+	for _, duplicate := range duplicates {
+		duplicateID, err := primitive.ObjectIDFromHex(duplicate)
+		if err != nil || duplicateID == canonicalID {
+			return canonicalID, nil, ErrNotFound
+		}
+		duplicateIDs[duplicateID] = true
+	}
+	return canonicalID, duplicateIDs, nil
+}
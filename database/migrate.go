@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Recipes used to keep their ingredients in two parallel arrays,
+// ingredients and ingredients_meta, joined by index. The migrations below
+// rewrite recipes, trashed recipes and revisions stored that way as
+// ingredient lines. They run when the store is opened and only touch
+// documents that have not been migrated yet.
+
+// legacyRecipeDocument holds the parallel arrays of a recipe stored in Mongo
+// before ingredient lines.
+type legacyRecipeDocument struct {
+	Ingredients     []primitive.ObjectID   `bson:"ingredients"`
+	IngredientsMeta []model.IngredientMeta `bson:"ingredientsmeta"`
+}
+
+func (db *DB) migrateIngredientLines() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	for _, migration := range []struct {
+		collection *mongo.Collection
+		field      string
+	}{
+		{db.recipeCollection, ""},
+		{db.trashCollection, ""},
+		{db.revisionCollection, "recipe"},
+	} {
+		migrated, err := migrateCollectionIngredientLines(ctx, migration.collection, migration.field)
+		if err != nil {
+			log.Printf("failed to migrate %s to ingredient lines: %v", migration.collection.Name(), err)
+		}
+		if migrated > 0 {
+			log.Printf("migrated %d documents in %s to ingredient lines", migrated, migration.collection.Name())
+		}
+	}
+}
+
+// migrateCollectionIngredientLines migrates the recipes in collection, or
+// the recipes nested under field in its documents.
+func migrateCollectionIngredientLines(ctx context.Context, collection *mongo.Collection, field string) (int, error) {
+	prefix := ""
+	if field != "" {
+		prefix = field + "."
+	}
+	cur, err := collection.Find(ctx, bson.M{
+		prefix + "ingredients":     bson.M{"$exists": true},
+		prefix + "ingredientlines": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+	migrated := 0
+	for cur.Next(ctx) {
+		document := cur.Current
+		if field != "" {
+			nested, ok := document.Lookup(field).DocumentOK()
+			if !ok {
+				continue
+			}
+			document = nested
+		}
+		var legacy legacyRecipeDocument
+		if err := bson.Unmarshal(document, &legacy); err != nil {
+			return migrated, err
+		}
+		lines, err := model.LinesFromLegacy(legacy.Ingredients, legacy.IngredientsMeta)
+		if err != nil {
+			log.Printf("skipping %s %v: %v", collection.Name(), cur.Current.Lookup("_id"), err)
+			continue
+		}
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": cur.Current.Lookup("_id")}, bson.M{
+			"$set":   bson.M{prefix + "ingredientlines": lines},
+			"$unset": bson.M{prefix + "ingredients": "", prefix + "ingredientsmeta": ""},
+		})
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cur.Err()
+}
+
+// migrateIngredientLines rewrites the documents of the key-value store that
+// have no ingredient lines. Decoding them converts the legacy arrays, see
+// model.Recipe.UnmarshalJSON.
+func (s *KVStore) migrateIngredientLines() error {
+	return s.backend.update(func(tx kvTx) error {
+		if err := migrateBucketIngredientLines[model.Recipe](tx, recipeBucket, ""); err != nil {
+			return err
+		}
+		if err := migrateBucketIngredientLines[model.TrashedRecipe](tx, trashBucket, ""); err != nil {
+			return err
+		}
+		return migrateBucketIngredientLines[model.RecipeRevision](tx, revisionBucket, "recipe")
+	})
+}
+
+func migrateBucketIngredientLines[T any](tx kvTx, bucket string, field string) error {
+	var legacy []string
+	err := tx.forEach(bucket, func(key string, value []byte) error {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(value, &document); err != nil {
+			return err
+		}
+		if field != "" {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(document[field], &nested); err != nil {
+				return err
+			}
+			document = nested
+		}
+		if _, ok := document["ingredient_lines"]; !ok {
+			legacy = append(legacy, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	migrated := 0
+	for _, key := range legacy {
+		document := getDocument[T](tx, bucket, key)
+		if document == nil {
+			log.Printf("skipping %s %s", bucket, key)
+			continue
+		}
+		if err := putDocument(tx, bucket, key, document); err != nil {
+			return err
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		log.Printf("migrated %d documents in %s to ingredient lines", migrated, bucket)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (db *DB) SavePantryItem(input *model.PantryItemWithoutID) (*model.PantryItem, error) {
+	item := input.WithID(primitive.NewObjectID().Hex())
+	if err := insertDocument(db.pantryCollection, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (db *DB) AllPantryItems() []*model.PantryItem {
+	items, err := findDocuments[model.PantryItem](db.pantryCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return items
+}
+
+func (db *DB) FindPantryItemByID(ID string) *model.PantryItem {
+	item, err := findDocument[model.PantryItem](db.pantryCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return item
+}
+
+func (db *DB) UpdatePantryItem(ID string, input *model.PantryItemWithoutID) (*model.PantryItem, error) {
+	if _, err := primitive.ObjectIDFromHex(ID); err != nil {
+		return nil, ErrNotFound
+	}
+	item := input.WithID(ID)
+	if err := replaceDocument(db.pantryCollection, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (db *DB) DeletePantryItem(ID string) (bool, error) {
+	return deleteDocument(db.pantryCollection, ID)
+}
+
+func (db *DB) PantryItemsUsingIngredient(ID string) ([]*model.PantryItem, error) {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return []*model.PantryItem{}, nil
+	}
+	return findDocuments[model.PantryItem](db.pantryCollection, bson.M{"ingredient": objectID})
+}
+
+// usedUp is the quantity below which a pantry item counts as used up, so
+// leftovers from floating point rounding are removed too.
+const usedUp = 1e-9
+
+// TakeFromPantry subtracts from each item with an update that Mongo applies
+// atomically, floored at zero, so concurrent changes are not lost. Mongo does
+// not give us a transaction across items without a replica set, so a
+// failure part way leaves the earlier items changed.
+func (db *DB) TakeFromPantry(amounts map[string]float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for ID, amount := range amounts {
+		objectID, err := primitive.ObjectIDFromHex(ID)
+		if err != nil {
+			continue
+		}
+		_, err = db.pantryCollection.UpdateOne(ctx, bson.M{"_id": objectID}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"quantity": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$quantity", amount}}}}}}},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = db.pantryCollection.DeleteOne(ctx, bson.M{"_id": objectID, "quantity": bson.M{"$lt": usedUp}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// saveRevisionAttempts bounds how often SaveRevision retries when another
+// revision of the same recipe took its number.
+const saveRevisionAttempts = 5
+
+// ensureRevisionIndexes makes revision numbers unique per recipe, so two
+// concurrent changes cannot both become the same revision.
+func (db *DB) ensureRevisionIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := db.revisionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipeid", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetName("recipe_revision_unique").SetUnique(true),
+	})
+	if err != nil {
+		log.Print("failed to create revision index: ", err)
+	}
+}
+
+func (db *DB) SaveRevision(revision *model.RecipeRevision) error {
+	var err error
+	for range saveRevisionAttempts {
+		revision.Number, err = db.nextRevisionNumber(revision.RecipeID)
+		if err != nil {
+			return err
+		}
+		revision.ID = primitive.NewObjectID().Hex()
+		err = insertDocument(db.revisionCollection, revision)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (db *DB) nextRevisionNumber(recipeID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var latest model.RecipeRevision
+	err := db.revisionCollection.FindOne(ctx, bson.M{"recipeid": recipeID}, options.FindOne().SetSort(bson.M{"number": -1})).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return latest.Number + 1, nil
+}
+
+func (db *DB) RecipeRevisions(recipeID string) ([]*model.RecipeRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cur, err := db.revisionCollection.Find(ctx, bson.M{"recipeid": recipeID}, options.Find().SetSort(bson.M{"number": 1}))
+	if err != nil {
+		return nil, err
+	}
+	revisions := []*model.RecipeRevision{}
+	if err := cur.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (db *DB) FindRevision(recipeID string, number int) *model.RecipeRevision {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	revision := model.RecipeRevision{}
+	err := db.revisionCollection.FindOne(ctx, bson.M{"recipeid": recipeID, "number": number}).Decode(&revision)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return &revision
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"log"
+	"rest/model"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type textSearchMatch struct {
+	ID              primitive.ObjectID     `bson:"_id"`
+	Score           float64                `bson:"score"`
+	IngredientLines []model.IngredientLine `bson:"ingredientlines"`
+}
+
+// ensureTextIndexes creates the text indexes SearchRecipes relies on. Mongo
+// allows one text index per collection, so the recipe index covers every
+// searchable recipe field.
+func (db *DB) ensureTextIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := db.recipeCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}, {Key: "steps", Value: "text"}},
+		Options: options.Index().SetName("recipe_text").SetWeights(bson.M{
+			"name":        searchWeightName,
+			"description": searchWeightDescription,
+			"steps":       searchWeightSteps,
+		}),
+	})
+	if err != nil {
+		log.Print("failed to create recipe text index: ", err)
+	}
+	_, err = db.ingredientCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: "text"}},
+		Options: options.Index().SetName("ingredient_text"),
+	})
+	if err != nil {
+		log.Print("failed to create ingredient text index: ", err)
+	}
+}
+
+// SearchRecipes combines a text search on recipes with a text search on
+// ingredient names: a recipe's score is its own text score plus the weighted
+// score of every matching ingredient it uses.
+func (db *DB) SearchRecipes(query string, category model.Category, limit int) []*model.RecipeSearchHit {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	scoreProjection := options.Find().SetProjection(bson.M{
+		"score":                      bson.M{"$meta": "textScore"},
+		"ingredientlines.ingredient": 1,
+	})
+
+	scores := map[primitive.ObjectID]float64{}
+	recipeFilter := bson.M{"$text": bson.M{"$search": query}}
+	if category != "" {
+		recipeFilter["category"] = category
+	}
+	recipeMatches, err := findTextMatches(ctx, db.recipeCollection, recipeFilter, scoreProjection)
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	for _, match := range recipeMatches {
+		scores[match.ID] += match.Score
+	}
+
+	ingredientMatches, err := findTextMatches(ctx, db.ingredientCollection, bson.M{"$text": bson.M{"$search": query}}, scoreProjection)
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	if len(ingredientMatches) > 0 {
+		ingredientScores := map[primitive.ObjectID]float64{}
+		ingredientIDs := make([]primitive.ObjectID, 0, len(ingredientMatches))
+		for _, match := range ingredientMatches {
+			ingredientScores[match.ID] = match.Score
+			ingredientIDs = append(ingredientIDs, match.ID)
+		}
+		filter := bson.M{"ingredientlines.ingredient": bson.M{"$in": ingredientIDs}}
+		if category != "" {
+			filter["category"] = category
+		}
+		recipes, err := findTextMatches(ctx, db.recipeCollection, filter, options.Find().SetProjection(bson.M{"ingredientlines.ingredient": 1}))
+		if err != nil {
+			log.Print(err)
+			return nil
+		}
+		for _, recipe := range recipes {
+			for _, ingredientID := range model.IngredientIDs(recipe.IngredientLines) {
+				scores[recipe.ID] += ingredientScores[ingredientID] * searchWeightIngredient
+			}
+		}
+	}
+
+	ranked := make([]textSearchMatch, 0, len(scores))
+	for ID, score := range scores {
+		ranked = append(ranked, textSearchMatch{ID: ID, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID.Hex() < ranked[j].ID.Hex()
+	})
+
+	hits := []*model.RecipeSearchHit{}
+	for _, match := range ranked {
+		if len(hits) >= limit {
+			break
+		}
+		recipe := db.FindRecipeByID(match.ID.Hex())
+		if recipe == nil {
+			continue
+		}
+		hits = append(hits, &model.RecipeSearchHit{Recipe: *recipe, Score: match.Score})
+	}
+	return hits
+}
+
+func findTextMatches(ctx context.Context, collection *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]textSearchMatch, error) {
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var matches []textSearchMatch
+	if err := cur.All(ctx, &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
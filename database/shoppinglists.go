@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (db *DB) SaveShoppingList(list *model.ShoppingList) error {
+	list.ID = primitive.NewObjectID().Hex()
+	return insertDocument(db.shoppingCollection, list)
+}
+
+func (db *DB) AllShoppingLists() []*model.ShoppingList {
+	lists, err := findDocuments[model.ShoppingList](db.shoppingCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return lists
+}
+
+func (db *DB) FindShoppingListByID(ID string) *model.ShoppingList {
+	list, err := findDocument[model.ShoppingList](db.shoppingCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return list
+}
+
+func (db *DB) CheckShoppingListItem(ID string, itemID int, checked bool) (*model.ShoppingList, error) {
+	list, err := findDocument[model.ShoppingList](db.shoppingCollection, ID)
+	if err != nil {
+		return nil, err
+	}
+	if !list.SetChecked(itemID, checked) {
+		return nil, ErrNotFound
+	}
+	if err := replaceDocument(db.shoppingCollection, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (db *DB) DeleteShoppingList(ID string) (bool, error) {
+	return deleteDocument(db.shoppingCollection, ID)
+}
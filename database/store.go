@@ -0,0 +1,183 @@
+package database
+
+import (
+	"rest/model"
+	"time"
+)
+
+// RecipeStore persists recipes and the recipe trash.
+type RecipeStore interface {
+	SaveRecipe(input *model.RecipeWithoutID) *model.Recipe
+	AllRecipes() []*model.Recipe
+	// ListRecipes returns one page of recipes and whether there are more
+	// after it.
+	ListRecipes(query model.ListQuery) ([]*model.Recipe, bool, error)
+	FindRecipesByCategory(category model.Category) []*model.Recipe
+	FindRecipeByID(ID string) *model.ResolvedRecipe
+	FindRecipeDocumentByID(ID string) *model.Recipe
+	FindRecipeByName(name string) *model.Recipe
+	// SearchRecipes runs a full-text query over recipe names, descriptions,
+	// steps and ingredient names, optionally limited to one category, and
+	// returns at most limit recipes, most relevant first.
+	SearchRecipes(query string, category model.Category, limit int) []*model.RecipeSearchHit
+	// UpdateRecipe replaces a recipe that is still at version and returns it
+	// at the next version. It returns ErrNotFound if there is no recipe with
+	// that ID, and ErrVersionMismatch if it is at another version.
+	UpdateRecipe(ID string, version int, input *model.RecipeWithoutID) (*model.ResolvedRecipe, error)
+	// DeleteRecipe moves a recipe that is still at version to the trash. It
+	// returns false if there is no recipe with that ID, and
+	// ErrVersionMismatch if it is at another version.
+	DeleteRecipe(ID string, version int) (bool, error)
+	// AllTrashedRecipes returns every recipe in the trash, or nil if they
+	// cannot be loaded.
+	AllTrashedRecipes() []*model.TrashedRecipe
+	// FindTrashedRecipe returns a recipe in the trash. It returns
+	// ErrNotFound if the trash has no recipe with that ID.
+	FindTrashedRecipe(ID string) (*model.TrashedRecipe, error)
+	RestoreRecipe(ID string) (bool, error)
+	PurgeTrash(before time.Time) (int64, error)
+	// RecipesUsingIngredient returns every recipe, including recipes in the
+	// trash, that lists the ingredient.
+	RecipesUsingIngredient(ID string) ([]*model.Recipe, error)
+}
+
+// IngredientStore persists ingredients.
+type IngredientStore interface {
+	SaveIngredient(input *model.IngredientWithoutID) *model.Ingredient
+	SaveIngredientWithID(input *model.Ingredient) *model.Ingredient
+	AllIngredients() []*model.Ingredient
+	// ListIngredients returns one page of ingredients and whether there are
+	// more after it. Category and Ingredient filters do not apply.
+	ListIngredients(query model.ListQuery) ([]*model.Ingredient, bool, error)
+	FindIngredientByID(ID string) *model.Ingredient
+	// FindIngredientByName finds the ingredient with a name or alias that
+	// is name, ignoring case and plurals.
+	FindIngredientByName(name string) *model.Ingredient
+	UpdateIngredient(ID string, input *model.IngredientWithoutID) (*model.Ingredient, error)
+	DeleteIngredient(ID string) (bool, error)
+	// MergeIngredients stores merged as the ingredient with ID, points every
+	// recipe line, pantry item and substitution that refers to one of the
+	// duplicates at it, and deletes the duplicates. Recipes that change go
+	// to the next version. If a step fails the merge is undone. It returns
+	// ErrNotFound if one of the ingredients does not exist, and
+	// ErrVersionMismatch if a recipe changed while it was being rewritten.
+	MergeIngredients(ID string, duplicates []string, merged *model.IngredientWithoutID) error
+	// DeleteIngredientCascade deletes the ingredient together with its
+	// recipe lines, including those of recipes in the trash, its pantry
+	// items and the substitutions that use it, since they cannot be applied
+	// without it. Recipes that change go to the next version. If a step
+	// fails the deletion is undone. It returns ErrNotFound if the ingredient
+	// does not exist, and ErrVersionMismatch if a recipe changed while it
+	// was being rewritten.
+	DeleteIngredientCascade(ID string) error
+}
+
+// SubstitutionStore persists ingredient substitutions.
+type SubstitutionStore interface {
+	// SaveSubstitution stores a new substitution and assigns its ID.
+	SaveSubstitution(input *model.SubstitutionWithoutID) (*model.Substitution, error)
+	AllSubstitutions() []*model.Substitution
+	FindSubstitutionByID(ID string) *model.Substitution
+	DeleteSubstitution(ID string) (bool, error)
+	// SubstitutionsUsingIngredient returns the substitutions that replace
+	// the ingredient or replace another one with it.
+	SubstitutionsUsingIngredient(ID string) ([]*model.Substitution, error)
+}
+
+// ShoppingListStore persists shopping lists.
+type ShoppingListStore interface {
+	// SaveShoppingList stores a new list and assigns its ID.
+	SaveShoppingList(list *model.ShoppingList) error
+	AllShoppingLists() []*model.ShoppingList
+	FindShoppingListByID(ID string) *model.ShoppingList
+	// CheckShoppingListItem checks or unchecks one item. It returns
+	// ErrNotFound if there is no such list or item.
+	CheckShoppingListItem(ID string, itemID int, checked bool) (*model.ShoppingList, error)
+	DeleteShoppingList(ID string) (bool, error)
+}
+
+// MealPlanStore persists meal plans.
+type MealPlanStore interface {
+	SaveMealPlan(input *model.MealPlanWithoutID) (*model.MealPlan, error)
+	AllMealPlans() []*model.MealPlan
+	FindMealPlanByID(ID string) *model.MealPlan
+	// UpdateMealPlan replaces a meal plan. It returns ErrNotFound if there
+	// is none with that ID.
+	UpdateMealPlan(ID string, input *model.MealPlanWithoutID) (*model.MealPlan, error)
+	DeleteMealPlan(ID string) (bool, error)
+}
+
+// PantryStore persists the pantry inventory.
+type PantryStore interface {
+	SavePantryItem(input *model.PantryItemWithoutID) (*model.PantryItem, error)
+	AllPantryItems() []*model.PantryItem
+	FindPantryItemByID(ID string) *model.PantryItem
+	UpdatePantryItem(ID string, input *model.PantryItemWithoutID) (*model.PantryItem, error)
+	DeletePantryItem(ID string) (bool, error)
+	// PantryItemsUsingIngredient returns the items of the ingredient.
+	PantryItemsUsingIngredient(ID string) ([]*model.PantryItem, error)
+	// TakeFromPantry subtracts amounts, keyed by item ID and in the items'
+	// own units, from several items at once. Quantities do not drop below
+	// zero, items that are used up are removed, and items deleted in the
+	// meantime are skipped.
+	TakeFromPantry(amounts map[string]float64) error
+}
+
+// RevisionStore persists recipe revisions. Revisions are immutable, so there
+// is no way to change or delete one.
+type RevisionStore interface {
+	// SaveRevision stores a new revision and assigns its ID and the next
+	// number for its recipe.
+	SaveRevision(revision *model.RecipeRevision) error
+	// RecipeRevisions returns the revisions of a recipe, oldest first.
+	RecipeRevisions(recipeID string) ([]*model.RecipeRevision, error)
+	FindRevision(recipeID string, number int) *model.RecipeRevision
+}
+
+// UserStore persists user accounts.
+type UserStore interface {
+	// SaveUser stores a new user and assigns its ID. The first user becomes
+	// an admin whatever its role; that is decided atomically with storing
+	// it, so two concurrent first sign ups cannot both become admins. It
+	// returns ErrUsernameTaken if another user has the same username.
+	SaveUser(user *model.User) error
+	FindUserByID(ID string) *model.User
+	FindUserByUsername(username string) *model.User
+	AllUsers() []*model.User
+	CountUsers() (int64, error)
+	// SetUserRole changes a user's role. It returns ErrNotFound if there is
+	// no user with that ID.
+	SetUserRole(ID string, role model.Role) error
+}
+
+// APIKeyStore persists API keys.
+type APIKeyStore interface {
+	// SaveAPIKey stores a new key and assigns its ID.
+	SaveAPIKey(key *model.APIKey) error
+	AllAPIKeys() []*model.APIKey
+	FindAPIKeyByID(ID string) *model.APIKey
+	FindAPIKeyByHash(hash string) *model.APIKey
+	// RevokeAPIKey marks a key as revoked. It returns ErrNotFound if there
+	// is none with that ID.
+	RevokeAPIKey(ID string, at time.Time) error
+	// RecordAPIKeyUse counts a request made with the key.
+	RecordAPIKeyUse(ID string, at time.Time) error
+}
+
+// Store is everything the API needs from a storage backend.
+type Store interface {
+	RecipeStore
+	IngredientStore
+	SubstitutionStore
+	RevisionStore
+	ShoppingListStore
+	MealPlanStore
+	PantryStore
+	UserStore
+	APIKeyStore
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*KVStore)(nil)
+)
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"rest/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (db *DB) SaveSubstitution(input *model.SubstitutionWithoutID) (*model.Substitution, error) {
+	substitution := input.WithID(primitive.NewObjectID().Hex())
+	if err := insertDocument(db.substitutionCollection, substitution); err != nil {
+		return nil, err
+	}
+	return substitution, nil
+}
+
+func (db *DB) AllSubstitutions() []*model.Substitution {
+	substitutions, err := findDocuments[model.Substitution](db.substitutionCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return substitutions
+}
+
+func (db *DB) FindSubstitutionByID(ID string) *model.Substitution {
+	substitution, err := findDocument[model.Substitution](db.substitutionCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return substitution
+}
+
+func (db *DB) DeleteSubstitution(ID string) (bool, error) {
+	return deleteDocument(db.substitutionCollection, ID)
+}
+
+func (db *DB) SubstitutionsUsingIngredient(ID string) ([]*model.Substitution, error) {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return []*model.Substitution{}, nil
+	}
+	return findDocuments[model.Substitution](db.substitutionCollection, usingIngredientFilter(objectID))
+}
+
+// usingIngredientFilter matches the substitutions for which
+// Substitution.UsesIngredient is true.
+func usingIngredientFilter(ID primitive.ObjectID) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"ingredient": ID},
+		bson.M{"replacements.ingredient": ID},
+	}}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rest/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureUserIndexes makes usernames unique, so two sign ups with the same
+// name cannot both succeed.
+func (db *DB) ensureUserIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := db.userCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetName("username_unique").SetUnique(true),
+	})
+	if err != nil {
+		log.Print("failed to create username index: ", err)
+	}
+}
+
+func (db *DB) SaveUser(user *model.User) error {
+	user.ID = primitive.NewObjectID().Hex()
+	first, err := db.claimFirstAdmin(user.ID)
+	if err != nil {
+		return err
+	}
+	if first {
+		user.Role = model.RoleAdmin
+	}
+	err = insertDocument(db.userCollection, user)
+	if err != nil && first {
+		db.releaseFirstAdmin()
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+// firstAdminSetting is the ID of the setting that records which user was
+// made the first admin. Only one insert of it can succeed, which makes
+// choosing the first admin atomic.
+const firstAdminSetting = "first_admin"
+
+// claimFirstAdmin reports whether the user with userID is the first user and
+// so becomes an admin. It is false once there are users, which also covers
+// databases from before the setting existed.
+func (db *DB) claimFirstAdmin(userID string) (bool, error) {
+	users, err := db.CountUsers()
+	if err != nil || users > 0 {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = db.settingsCollection.InsertOne(ctx, bson.M{"_id": firstAdminSetting, "user_id": userID})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// releaseFirstAdmin undoes claimFirstAdmin when the user could not be
+// stored.
+func (db *DB) releaseFirstAdmin() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := db.settingsCollection.DeleteOne(ctx, bson.M{"_id": firstAdminSetting}); err != nil {
+		log.Print("failed to release the first admin: ", err)
+	}
+}
+
+func (db *DB) FindUserByID(ID string) *model.User {
+	user, err := findDocument[model.User](db.userCollection, ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return user
+}
+
+func (db *DB) FindUserByUsername(username string) *model.User {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	user := model.User{}
+	err := db.userCollection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Print(err)
+		}
+		return nil
+	}
+	return &user
+}
+
+func (db *DB) CountUsers() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return db.userCollection.CountDocuments(ctx, bson.M{})
+}
+
+func (db *DB) AllUsers() []*model.User {
+	users, err := findDocuments[model.User](db.userCollection, bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+	return users
+}
+
+func (db *DB) SetUserRole(ID string, role model.Role) error {
+	objectID, err := primitive.ObjectIDFromHex(ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := db.userCollection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
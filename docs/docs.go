@@ -24,28 +24,225 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/ingredients": {
+        "/api-keys": {
             "get": {
-                "description": "get all ingredients",
+                "description": "get the caller's API keys, or every key for admins",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all ingredients",
-                "operationId": "allingredients",
+                "summary": "Get API keys",
+                "operationId": "allapikeys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Ingredient"
+                                "$ref": "#/definitions/model.APIKeyInfo"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create an API key for the caller. The key is only included in this response; send it as the X-API-Key header. A key can only have scopes the caller's role grants.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create an API key",
+                "operationId": "addapikey",
+                "parameters": [
+                    {
+                        "description": "API key",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.APIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api-keys/{id}": {
+            "get": {
+                "description": "get an API key, including how often it has been used",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get API key by ID",
+                "operationId": "getapikey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.APIKeyInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "revoke an API key. Requests made with it are rejected from then on.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Revoke an API key",
+                "operationId": "revokeapikey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "exchange a username and password for a token. Send it as \"Authorization: Bearer \u003ctoken\u003e\" to make changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Log in",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "description": "Username and password",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingredients": {
+            "get": {
+                "description": "get one page of ingredients. Follow next_cursor, or the Link header, for the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all ingredients",
+                "operationId": "allingredients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name, -name, created (default) or -created",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.IngredientPage"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Add ingredient",
+                "description": "add an ingredient. Names and aliases must differ from those of other ingredients in more than case and plurals. When the name is close to an existing one, the ingredient is still added, with a warning and the close matches in did_you_mean.",
                 "produces": [
                     "application/json"
                 ],
@@ -66,7 +263,13 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Ingredient"
+                            "$ref": "#/definitions/model.AddedIngredient"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
                         }
                     }
                 }
@@ -74,7 +277,7 @@ const docTemplate = `{
         },
         "/ingredients/generate": {
             "post": {
-                "description": "Generate ingredients",
+                "description": "Generate ingredients from the test data. Admin only, and only available when the server runs with PROFILE=development.",
                 "produces": [
                     "application/json"
                 ],
@@ -93,147 +296,3088 @@ const docTemplate = `{
                 }
             }
         },
-        "/ingredients/{name}": {
+        "/ingredients/lookup": {
             "get": {
-                "description": "get ingredient by name",
+                "description": "find the ingredients whose name or alias is close to a name, allowing for case, plurals and typos, closest first",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get ingredient by name",
-                "operationId": "ingredientbyname",
+                "summary": "Find ingredients by an approximate name",
+                "operationId": "lookupingredients",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name",
+                        "description": "Name to look up",
                         "name": "name",
-                        "in": "path",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of candidates (default 5, max 50)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Ingredient"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.IngredientCandidate"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/recipes": {
-            "get": {
-                "description": "get all recipes",
+        "/ingredients/parse": {
+            "post": {
+                "description": "read lines like \"2 1/2 cups all-purpose flour, sifted\" as quantity, unit, ingredient and preparation note, and match each to an ingredient by name. Lines ending in a colon are group headings. With create_missing, ingredients that do not exist yet are added, which needs the ingredients:write scope.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all recipes",
-                "operationId": "allrecipes",
+                "summary": "Read ingredient lines from text",
+                "operationId": "parseingredients",
+                "parameters": [
+                    {
+                        "description": "Lines of text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ParseIngredientsRequest"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Recipe"
+                                "$ref": "#/definitions/model.ParsedIngredientLine"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "get a recipe by ID",
+            }
+        },
+        "/ingredients/{id}": {
+            "put": {
+                "description": "replace an ingredient, for example to rename it",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Add a recipe",
-                "operationId": "addrecipe",
+                "summary": "Replace an ingredient",
+                "operationId": "replaceingredient",
                 "parameters": [
                     {
-                        "description": "Recipe",
-                        "name": "recipe",
+                        "type": "string",
+                        "description": "Ingredient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ingredient",
+                        "name": "ingredient",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Recipe"
+                            "$ref": "#/definitions/model.IngredientWithoutID"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.RecipeWithoutID"
+                            "$ref": "#/definitions/model.Ingredient"
                         }
                     }
                 }
-            }
-        },
-        "/recipes/generate": {
-            "post": {
-                "description": "Generate recipes",
+            },
+            "delete": {
+                "description": "delete an ingredient. Ingredients used by recipes, pantry items or substitutions are only deleted with force=cascade, which also removes them from those recipes and deletes the pantry items and substitutions.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Generate recipe",
-                "operationId": "generaterecipe",
+                "summary": "Delete an ingredient",
+                "operationId": "deleteingredient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ingredient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to cascade to remove the ingredient from recipes, the pantry and substitutions",
+                        "name": "force",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Recipe"
-                            }
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.IngredientInUseResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update some fields of an ingredient using JSON Merge Patch (RFC 7386)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update an ingredient",
+                "operationId": "patchingredient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ingredient ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.IngredientWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Ingredient"
                         }
                     }
                 }
             }
         },
-        "/recipes/{id}": {
-            "get": {
-                "description": "get a recipe by ID",
+        "/ingredients/{id}/merge": {
+            "post": {
+                "description": "merge duplicates into an ingredient. Recipes, including those in the trash, pantry items and substitutions that use a duplicate are changed to use the ingredient, the names of the duplicates become its aliases, and the duplicates are deleted. With dry_run nothing changes, and the response lists what would.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get recipe by ID",
-                "operationId": "getrecipe",
+                "summary": "Merge duplicate ingredients",
+                "operationId": "mergeingredients",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Recipe ID",
+                        "description": "ID of the ingredient to keep",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Duplicates",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MergeIngredientsRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Recipe"
+                            "$ref": "#/definitions/model.IngredientMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.Category": {
-            "type": "string",
-            "enum": [
+        },
+        "/ingredients/{name}": {
+            "get": {
+                "description": "get an ingredient by its name or one of its aliases, ignoring case and plurals",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get ingredient by name",
+                "operationId": "ingredientbyname",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Ingredient"
+                        }
+                    }
+                }
+            }
+        },
+        "/meal-plans": {
+            "get": {
+                "description": "get the caller's meal plans, or every plan for admins",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all meal plans",
+                "operationId": "allmealplans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MealPlan"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add a meal plan: recipes on a calendar of days and meal slots (breakfast, lunch, snack, dinner)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add a meal plan",
+                "operationId": "addmealplan",
+                "parameters": [
+                    {
+                        "description": "Meal plan",
+                        "name": "plan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlanWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/meal-plans/{id}": {
+            "get": {
+                "description": "get a meal plan by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get meal plan by ID",
+                "operationId": "getmealplan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlan"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "replace the name and every entry of a meal plan",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replace a meal plan",
+                "operationId": "replacemealplan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Meal plan",
+                        "name": "plan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlanWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete a meal plan",
+                "summary": "Delete a meal plan",
+                "operationId": "deletemealplan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/meal-plans/{id}/calendar.ics": {
+            "get": {
+                "description": "export a meal plan as an iCalendar (.ics) file with one event per meal",
+                "produces": [
+                    "text/calendar"
+                ],
+                "summary": "Export a meal plan as a calendar",
+                "operationId": "exportmealplan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/meal-plans/{id}/copy-week": {
+            "post": {
+                "description": "copy the entries of one week, Monday to Sunday, to the week after it. Entries already planned in the next week are kept, and are not copied twice.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Copy a week of a meal plan",
+                "operationId": "copymealplanweek",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Any date in the week to copy",
+                        "name": "week",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CopyWeekRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MealPlan"
+                        }
+                    }
+                }
+            }
+        },
+        "/meal-plans/{id}/shopping-list": {
+            "post": {
+                "description": "create a saved shopping list with everything needed for the meals in a plan, optionally only those between two dates. Meals whose recipe was deleted are left out and their recipe IDs listed under deleted_recipes.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Make a shopping list from a meal plan",
+                "operationId": "mealplanshoppinglist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day to shop for, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day to shop for, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pantry": {
+            "get": {
+                "description": "get every item in the caller's pantry, or in every pantry for admins",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the pantry",
+                "operationId": "allpantryitems",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PantryItem"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add an amount of an ingredient to the pantry",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add a pantry item",
+                "operationId": "addpantryitem",
+                "parameters": [
+                    {
+                        "description": "Pantry item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PantryItemWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.PantryItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pantry/expiring": {
+            "get": {
+                "description": "list the caller's pantry items that expire within the given number of days, or have already expired, with the recipes that would use them up, most expiring ingredients first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find expiring pantry items",
+                "operationId": "expiringpantryitems",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Days from today (default 3)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ExpiringPantryResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pantry/{id}": {
+            "get": {
+                "description": "get a pantry item by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get pantry item by ID",
+                "operationId": "getpantryitem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pantry item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PantryItem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "replace every field of a pantry item",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replace a pantry item",
+                "operationId": "replacepantryitem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pantry item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pantry item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PantryItemWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PantryItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove an item from the pantry",
+                "summary": "Delete a pantry item",
+                "operationId": "deletepantryitem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pantry item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/recipes": {
+            "get": {
+                "description": "get one page of recipes. Follow next_cursor, or the Link header, for the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all recipes",
+                "operationId": "allrecipes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only return recipes in this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return recipes that use this ingredient, by ID or name",
+                        "name": "ingredient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Leave out recipes with any of these allergens, comma separated, or with ingredients whose allergens are not known",
+                        "name": "exclude_allergens",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return recipes whose ingredients all suit this diet: vegan, vegetarian or pescatarian",
+                        "name": "diet",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name, -name, created (default) or -created",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert quantities to metric or imperial units",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add a recipe. Instead of ingredient_lines, the ingredients can be sent as lines of text in ingredients_text, which are read like POST /ingredients/parse does; set create_missing_ingredients to add ingredients that do not exist yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add a recipe",
+                "operationId": "addrecipe",
+                "parameters": [
+                    {
+                        "description": "Recipe",
+                        "name": "recipe",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Recipe"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Change message for the revision history",
+                        "name": "message",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Recipe"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the new recipe"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/cookable": {
+            "post": {
+                "description": "rank recipes by how many of their ingredients are on hand, and list what is missing for each one",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Find recipes you can cook",
+                "operationId": "cookablerecipes",
+                "parameters": [
+                    {
+                        "description": "Ingredient names or IDs on hand",
+                        "name": "pantry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CookableQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CookableResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/generate": {
+            "post": {
+                "description": "Generate recipes from the test data. Admin only, and only available when the server runs with PROFILE=development.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Generate recipe",
+                "operationId": "generaterecipe",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Recipe"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/search": {
+            "get": {
+                "description": "full-text search across recipe names, descriptions, steps and ingredient names, ranked by relevance with highlighted snippets",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Search recipes",
+                "operationId": "searchrecipes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return recipes in this category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert quantities to metric or imperial units",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RecipeSearchHit"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/trash": {
+            "get": {
+                "description": "get the recipes in the trash that the caller can restore: their own, or all of them for an admin",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get deleted recipes",
+                "operationId": "trashedrecipes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TrashedRecipe"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}": {
+            "get": {
+                "description": "get a recipe by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get recipe by ID",
+                "operationId": "getrecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a copy of the recipe the caller has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Scale ingredient quantities to this many servings",
+                        "name": "servings",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Convert quantities to metric or imperial units",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolvedRecipe"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "replace every field of a recipe",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replace a recipe",
+                "operationId": "replacerecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the recipe being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Recipe",
+                        "name": "recipe",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeWithoutID"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Change message for the revision history",
+                        "name": "message",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolvedRecipe"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "move a recipe to the trash",
+                "summary": "Delete a recipe",
+                "operationId": "deleterecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the recipe being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update some fields of a recipe using JSON Merge Patch (RFC 7386)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a recipe",
+                "operationId": "patchrecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the recipe being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeWithoutID"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Change message for the revision history",
+                        "name": "message",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolvedRecipe"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/cooked": {
+            "post": {
+                "description": "take the recipe's ingredients out of the caller's pantry, using the items that expire first. Ingredients the pantry does not have enough of, or has in units that cannot be converted, are listed as missing.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Mark a recipe as cooked",
+                "operationId": "recipecooked",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Servings cooked, if not what the recipe makes",
+                        "name": "servings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CookedResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/nutrition": {
+            "get": {
+                "description": "add up the nutrition facts of a recipe's ingredients, in total and per serving. Ingredient lines without nutrition data, without a quantity, or in a unit that cannot be converted to grams are not counted and are listed in missing, as are lines whose ingredient was deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the nutritional value of a recipe",
+                "operationId": "recipenutrition",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeNutrition"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/restore": {
+            "post": {
+                "description": "move a recipe from the trash back to the recipes",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Restore a deleted recipe",
+                "operationId": "restorerecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolvedRecipe"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/revisions": {
+            "get": {
+                "description": "get every revision of a recipe, oldest first, with who made it, when, and the change message",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the revisions of a recipe",
+                "operationId": "reciperevisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RecipeRevision"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/revisions/diff": {
+            "get": {
+                "description": "show what changed between two revisions of a recipe: name, description, category and servings, steps added or removed, and ingredients added, removed or with a changed quantity",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Compare two revisions of a recipe",
+                "operationId": "diffreciperevisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Older revision (default the one before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Newer revision (default the latest)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/revisions/{number}": {
+            "get": {
+                "description": "get a recipe as it was in one revision",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a revision of a recipe",
+                "operationId": "getreciperevision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeRevision"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/revisions/{number}/revert": {
+            "post": {
+                "description": "change a recipe back to how it was in an earlier revision. This adds a new revision; later revisions are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Revert a recipe",
+                "operationId": "revertrecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the recipe being changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Change message",
+                        "name": "message",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ResolvedRecipe"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{id}/substitutions": {
+            "get": {
+                "description": "replace the ingredients of a recipe that are missing, contain one of the excluded allergens or do not suit the diet, using the substitutions between ingredients. Quantities are adjusted by the substitutions' ratios. Ingredients whose allergens are not known count as containing every allergen. Ingredients without a usable substitution are listed in unreplaced; optional ones are left out of the variant.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a variant of a recipe with ingredients substituted",
+                "operationId": "recipesubstitutions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ingredients that are not available, by ID or name, comma separated",
+                        "name": "missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replace ingredients with any of these allergens, comma separated",
+                        "name": "exclude_allergens",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replace ingredients that do not suit this diet: vegan, vegetarian or pescatarian",
+                        "name": "diet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecipeVariant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping-lists": {
+            "get": {
+                "description": "get the caller's saved shopping lists, or every list for admins, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all shopping lists",
+                "operationId": "allshoppinglists",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ShoppingList"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "combine the ingredients of several recipes into one saved shopping list. The same ingredient is merged into one line where its units can be converted, and lines are grouped by aisle. Recipe lines whose ingredient was deleted are listed under unresolved.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a shopping list",
+                "operationId": "addshoppinglist",
+                "parameters": [
+                    {
+                        "description": "Recipes to shop for",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingListRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping-lists/{id}": {
+            "get": {
+                "description": "get a shopping list by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get shopping list by ID",
+                "operationId": "getshoppinglist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shopping list ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingList"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete a shopping list",
+                "summary": "Delete a shopping list",
+                "operationId": "deleteshoppinglist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shopping list ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/shopping-lists/{id}/export": {
+            "get": {
+                "description": "export a shopping list as plain text with check boxes, or as CSV",
+                "produces": [
+                    "text/plain",
+                    "text/csv"
+                ],
+                "summary": "Export a shopping list",
+                "operationId": "exportshoppinglist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shopping list ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "text (default) or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping-lists/{id}/items/{item}": {
+            "patch": {
+                "description": "check or uncheck one item of a shopping list",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check off a shopping list item",
+                "operationId": "checkshoppinglistitem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shopping list ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Checked state",
+                        "name": "update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingListItemUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ShoppingList"
+                        }
+                    }
+                }
+            }
+        },
+        "/substitutions": {
+            "get": {
+                "description": "get every ingredient substitution",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all substitutions",
+                "operationId": "allsubstitutions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Substitution"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add a way to replace an ingredient with one or more others. The quantity of each replacement is the replaced quantity times its ratio, in the replaced unit unless the replacement has its own unit.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add a substitution",
+                "operationId": "addsubstitution",
+                "parameters": [
+                    {
+                        "description": "Substitution",
+                        "name": "substitution",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SubstitutionWithoutID"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Substitution"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/substitutions/{id}": {
+            "get": {
+                "description": "get an ingredient substitution by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get substitution by ID",
+                "operationId": "getsubstitution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Substitution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Substitution"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete an ingredient substitution",
+                "summary": "Delete a substitution",
+                "operationId": "deletesubstitution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Substitution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "get every user account. Only admins can list users.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get users",
+                "operationId": "allusers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.UserInfo"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create a user account. New accounts are viewers, except the first one, which becomes an admin. An admin can promote them with PUT /users/{id}/role.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a user account",
+                "operationId": "register",
+                "parameters": [
+                    {
+                        "description": "Username and password",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "description": "get the account the request's token belongs to",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the logged in user",
+                "operationId": "currentuser",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/role": {
+            "put": {
+                "description": "make a user a viewer, an editor or an admin. Only admins can change roles, and the last admin cannot be demoted.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Change a user's role",
+                "operationId": "setuserrole",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RoleUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/rest.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.APIKeyInfo": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the start of the key, so users can tell their keys apart.",
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "description": "RateLimit is the number of requests per minute the key may make.",
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Scope"
+                    }
+                },
+                "usage": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.APIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Scope"
+                    }
+                }
+            }
+        },
+        "model.AddedIngredient": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "aisle": {
+                    "description": "Aisle is where the ingredient is found in a store, used to group\nshopping lists.",
+                    "type": "string"
+                },
+                "aliases": {
+                    "description": "Aliases are other names the ingredient goes by, like \"scallions\" for\nspring onions. Lookups by name find an ingredient by its aliases too.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allergens": {
+                    "description": "Allergens the ingredient contains. Nil means they are not known, an\nempty list that it contains none.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Allergen"
+                    }
+                },
+                "density": {
+                    "description": "Density in grams per millilitre, used to convert between volume and\nmass. Zero when unknown.",
+                    "type": "number"
+                },
+                "did_you_mean": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IngredientCandidate"
+                    }
+                },
+                "diets": {
+                    "description": "Diets the ingredient is suitable for. Marking it vegan is enough for\nvegetarian and pescatarian too.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Diet"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nutrition": {
+                    "description": "Nutrition is per 100 g, or nil when unknown.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Nutrition"
+                        }
+                    ]
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "warning": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Allergen": {
+            "type": "string",
+            "enum": [
+                "gluten",
+                "dairy",
+                "egg",
+                "nuts",
+                "peanuts",
+                "soy",
+                "fish",
+                "shellfish",
+                "molluscs",
+                "sesame",
+                "celery",
+                "mustard",
+                "lupin",
+                "sulphites"
+            ],
+            "x-enum-varnames": [
+                "AllergenGluten",
+                "AllergenDairy",
+                "AllergenEgg",
+                "AllergenNuts",
+                "AllergenPeanuts",
+                "AllergenSoy",
+                "AllergenFish",
+                "AllergenShellfish",
+                "AllergenMolluscs",
+                "AllergenSesame",
+                "AllergenCelery",
+                "AllergenMustard",
+                "AllergenLupin",
+                "AllergenSulphites"
+            ]
+        },
+        "model.AppliedSubstitution": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reason": {
+                    "$ref": "#/definitions/model.SubstitutionReason"
+                },
+                "replaced": {
+                    "$ref": "#/definitions/model.ResolvedIngredientLine"
+                },
+                "with": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ResolvedIngredientLine"
+                    }
+                }
+            }
+        },
+        "model.Category": {
+            "type": "string",
+            "enum": [
                 "DRINK",
                 "MAIN_COURSE",
                 "DESSERT",
                 "APPETIZER"
             ],
             "x-enum-varnames": [
-                "CategoryDrink",
-                "CategoryMainCourse",
-                "CategoryDessert",
-                "CategoryAppetizer"
+                "CategoryDrink",
+                "CategoryMainCourse",
+                "CategoryDessert",
+                "CategoryAppetizer"
+            ]
+        },
+        "model.ChangeKind": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "changed"
+            ],
+            "x-enum-varnames": [
+                "ChangeAdded",
+                "ChangeRemoved",
+                "ChangeChanged"
+            ]
+        },
+        "model.CookableQuery": {
+            "type": "object",
+            "properties": {
+                "ingredients": {
+                    "description": "Ingredients are the names or IDs of the ingredients on hand.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "max_missing": {
+                    "description": "MaxMissing leaves out recipes that miss more ingredients than this.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CookableRecipe": {
+            "type": "object",
+            "properties": {
+                "coverage": {
+                    "description": "Coverage is the share of the recipe's required ingredient lines that\nare on hand, from 0 to 1. Optional lines are not counted.",
+                    "type": "number"
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MissingIngredient"
+                    }
+                },
+                "recipe": {
+                    "$ref": "#/definitions/model.Recipe"
+                }
+            }
+        },
+        "model.CookableResponse": {
+            "type": "object",
+            "properties": {
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CookableRecipe"
+                    }
+                },
+                "unknown_ingredients": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.CookedResponse": {
+            "type": "object",
+            "properties": {
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MissingIngredient"
+                    }
+                },
+                "used": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PantryUsage"
+                    }
+                }
+            }
+        },
+        "model.CopyWeekRequest": {
+            "type": "object",
+            "properties": {
+                "week": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the start of the key, so users can tell their keys apart.",
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "description": "RateLimit is the number of requests per minute the key may make.",
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Scope"
+                    }
+                },
+                "usage": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Credentials": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Diet": {
+            "type": "string",
+            "enum": [
+                "vegan",
+                "vegetarian",
+                "pescatarian"
+            ],
+            "x-enum-varnames": [
+                "DietVegan",
+                "DietVegetarian",
+                "DietPescatarian"
+            ]
+        },
+        "model.ExpiringPantryItem": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "days_left": {
+                    "description": "DaysLeft is negative for items that have already expired.",
+                    "type": "integer"
+                },
+                "expiry_date": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "type": "string"
+                },
+                "ingredient_name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "purchase_date": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExpiringPantryResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExpiringPantryItem"
+                    }
+                },
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExpiringRecipe"
+                    }
+                }
+            }
+        },
+        "model.ExpiringRecipe": {
+            "type": "object",
+            "properties": {
+                "recipe": {
+                    "$ref": "#/definitions/model.RecipeSummary"
+                },
+                "uses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Ingredient"
+                    }
+                }
+            }
+        },
+        "model.FieldChange": {
+            "type": "object",
+            "properties": {
+                "from": {},
+                "to": {}
+            }
+        },
+        "model.Ingredient": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "aisle": {
+                    "description": "Aisle is where the ingredient is found in a store, used to group\nshopping lists.",
+                    "type": "string"
+                },
+                "aliases": {
+                    "description": "Aliases are other names the ingredient goes by, like \"scallions\" for\nspring onions. Lookups by name find an ingredient by its aliases too.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allergens": {
+                    "description": "Allergens the ingredient contains. Nil means they are not known, an\nempty list that it contains none.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Allergen"
+                    }
+                },
+                "density": {
+                    "description": "Density in grams per millilitre, used to convert between volume and\nmass. Zero when unknown.",
+                    "type": "number"
+                },
+                "diets": {
+                    "description": "Diets the ingredient is suitable for. Marking it vegan is enough for\nvegetarian and pescatarian too.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Diet"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nutrition": {
+                    "description": "Nutrition is per 100 g, or nil when unknown.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Nutrition"
+                        }
+                    ]
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.IngredientCandidate": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "Distance is the number of edits between the names, 0 when they are\nthe same but for case and plurals.",
+                    "type": "integer"
+                },
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "matched_name": {
+                    "description": "MatchedName is the name or alias that is closest.",
+                    "type": "string"
+                }
+            }
+        },
+        "model.IngredientChange": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "$ref": "#/definitions/model.ChangeKind"
+                },
+                "from": {
+                    "$ref": "#/definitions/model.IngredientLine"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "to": {
+                    "$ref": "#/definitions/model.IngredientLine"
+                }
+            }
+        },
+        "model.IngredientLine": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "description": "Group is the heading the line is listed under, like \"For the sauce\".",
+                    "type": "string"
+                },
+                "ingredient": {
+                    "type": "string"
+                },
+                "note": {
+                    "description": "Note says how the ingredient is prepared, like \"finely chopped\".",
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.IngredientMatch": {
+            "type": "string",
+            "enum": [
+                "exact",
+                "fuzzy",
+                "created"
+            ],
+            "x-enum-varnames": [
+                "MatchExact",
+                "MatchFuzzy",
+                "MatchCreated"
+            ]
+        },
+        "model.IngredientMerge": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "ingredient": {
+                    "description": "Ingredient is the ingredient the duplicates were merged into, as it\nis after the merge.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Ingredient"
+                        }
+                    ]
+                },
+                "merged": {
+                    "description": "Merged are the duplicates, which are deleted.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Ingredient"
+                    }
+                },
+                "pantry_items": {
+                    "type": "integer"
+                },
+                "recipes": {
+                    "description": "Recipes are the recipes, including those in the trash, whose lines\nare pointed at Ingredient.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RecipeSummary"
+                    }
+                },
+                "substitutions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.IngredientPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Ingredient"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.IngredientWithoutID": {
+            "type": "object",
+            "properties": {
+                "aisle": {
+                    "type": "string"
+                },
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allergens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Allergen"
+                    }
+                },
+                "density": {
+                    "type": "number"
+                },
+                "diets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Diet"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nutrition": {
+                    "$ref": "#/definitions/model.Nutrition"
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MealPlan": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MealPlanEntry"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MealPlanEntry": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "recipe_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer"
+                },
+                "slot": {
+                    "$ref": "#/definitions/model.MealSlot"
+                }
+            }
+        },
+        "model.MealPlanWithoutID": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MealPlanEntry"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "description": "OwnerID is set from the caller; the one in a request body is ignored.",
+                    "type": "string"
+                }
+            }
+        },
+        "model.MealSlot": {
+            "type": "string",
+            "enum": [
+                "breakfast",
+                "lunch",
+                "snack",
+                "dinner"
+            ],
+            "x-enum-varnames": [
+                "Breakfast",
+                "Lunch",
+                "Snack",
+                "Dinner"
+            ]
+        },
+        "model.MergeIngredientsRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "DryRun only reports what merging would change.",
+                    "type": "boolean"
+                },
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.MissingIngredient": {
+            "type": "object",
+            "properties": {
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Nutrition": {
+            "type": "object",
+            "properties": {
+                "carbohydrates": {
+                    "type": "number"
+                },
+                "energy": {
+                    "type": "number"
+                },
+                "fat": {
+                    "type": "number"
+                },
+                "fiber": {
+                    "type": "number"
+                },
+                "protein": {
+                    "type": "number"
+                },
+                "salt": {
+                    "type": "number"
+                },
+                "sugar": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.NutritionGap": {
+            "type": "object",
+            "properties": {
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "reason": {
+                    "$ref": "#/definitions/model.NutritionGapReason"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.NutritionGapReason": {
+            "type": "string",
+            "enum": [
+                "no_nutrition_data",
+                "no_quantity",
+                "unconvertible_unit",
+                "unknown_ingredient"
+            ],
+            "x-enum-varnames": [
+                "GapNoData",
+                "GapNoQuantity",
+                "GapUnconvertible",
+                "GapUnknownIngredient"
             ]
         },
-        "model.Ingredient": {
+        "model.PantryItem": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "expiry_date": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "purchase_date": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PantryItemWithoutID": {
+            "type": "object",
+            "properties": {
+                "expiry_date": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "description": "OwnerID is set from the caller; the one in a request body is ignored.",
+                    "type": "string"
+                },
+                "purchase_date": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PantryUsage": {
+            "type": "object",
+            "properties": {
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "remaining": {
+                    "description": "Remaining is what is left of the item. Items that are used up are\nremoved from the pantry.",
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ParseIngredientsRequest": {
+            "type": "object",
+            "properties": {
+                "create_missing": {
+                    "description": "CreateMissing adds ingredients that match no existing one, instead\nof reporting the line as unmatched.",
+                    "type": "boolean"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.ParsedIngredientLine": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "match": {
+                    "$ref": "#/definitions/model.IngredientMatch"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Recipe": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "$ref": "#/definitions/model.Category"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "ingredient_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IngredientLine"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "description": "Version counts the changes to the recipe, starting at 1. It is the\nrecipe's ETag; recipes stored before it was kept are at 0.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RecipeClassification": {
+            "type": "object",
+            "properties": {
+                "allergens": {
+                    "description": "Allergens are the allergens of all ingredients, optional ones\nincluded.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Allergen"
+                    }
+                },
+                "diets": {
+                    "description": "Diets are the diets every ingredient is suitable for.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Diet"
+                    }
+                },
+                "unclassified": {
+                    "description": "Unclassified names the ingredients whose allergens are not known.\nWhile there are any, the recipe may contain allergens that are not in\nAllergens.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unresolved": {
+                    "description": "Unresolved counts the lines whose ingredient no longer exists. While\nthere are any, the recipe may contain allergens that are not in\nAllergens, and it fits no diet.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RecipeDiff": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/model.FieldChange"
+                },
+                "description": {
+                    "$ref": "#/definitions/model.FieldChange"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IngredientChange"
+                    }
+                },
+                "name": {
+                    "$ref": "#/definitions/model.FieldChange"
+                },
+                "servings": {
+                    "$ref": "#/definitions/model.FieldChange"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StepChange"
+                    }
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.RecipeNutrition": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "description": "Complete is true when every ingredient line was counted.",
+                    "type": "boolean"
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.NutritionGap"
+                    }
+                },
+                "per_serving": {
+                    "description": "PerServing is left out when the recipe does not say how many\nservings it makes.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Nutrition"
+                        }
+                    ]
+                },
+                "recipe": {
+                    "$ref": "#/definitions/model.RecipeSummary"
+                },
+                "servings": {
+                    "type": "integer"
+                },
+                "total": {
+                    "$ref": "#/definitions/model.Nutrition"
+                }
+            }
+        },
+        "model.RecipePage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Recipe"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RecipeRevision": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "author_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "number": {
+                    "description": "Number counts the revisions of a recipe, starting at 1.",
+                    "type": "integer"
+                },
+                "recipe": {
+                    "$ref": "#/definitions/model.RecipeWithoutID"
+                },
+                "recipe_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RecipeSearchHit": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SearchHighlight"
+                    }
+                },
+                "recipe": {
+                    "$ref": "#/definitions/model.ResolvedRecipe"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.RecipeSummary": {
             "type": "object",
             "properties": {
                 "_id": {
@@ -244,10 +3388,73 @@ const docTemplate = `{
                 }
             }
         },
-        "model.IngredientMeta": {
+        "model.RecipeVariant": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "description": "Complete is true when every ingredient that had to go was replaced\nor left out.",
+                    "type": "boolean"
+                },
+                "recipe": {
+                    "$ref": "#/definitions/model.ResolvedRecipe"
+                },
+                "substitutions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AppliedSubstitution"
+                    }
+                },
+                "unreplaced": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UnreplacedIngredient"
+                    }
+                }
+            }
+        },
+        "model.RecipeWithoutID": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/model.Category"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "ingredient_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IngredientLine"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "description": "Version is set by the store; the one in a request body is ignored.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Replacement": {
             "type": "object",
             "properties": {
-                "quantity": {
+                "ingredient": {
+                    "type": "string"
+                },
+                "ratio": {
                     "type": "number"
                 },
                 "unit": {
@@ -255,15 +3462,30 @@ const docTemplate = `{
                 }
             }
         },
-        "model.IngredientWithoutID": {
+        "model.ResolvedIngredientLine": {
             "type": "object",
             "properties": {
-                "name": {
+                "group": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
                     "type": "string"
                 }
             }
         },
-        "model.Recipe": {
+        "model.ResolvedRecipe": {
             "type": "object",
             "properties": {
                 "_id": {
@@ -272,61 +3494,404 @@ const docTemplate = `{
                 "category": {
                     "$ref": "#/definitions/model.Category"
                 },
+                "classification": {
+                    "$ref": "#/definitions/model.RecipeClassification"
+                },
                 "description": {
                     "type": "string"
                 },
-                "ingredients": {
+                "ingredient_lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ResolvedIngredientLine"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer"
+                },
+                "steps": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "ingredients_meta": {
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Role": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "editor",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "RoleViewer",
+                "RoleEditor",
+                "RoleAdmin"
+            ]
+        },
+        "model.RoleUpdate": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "$ref": "#/definitions/model.Role"
+                }
+            }
+        },
+        "model.Scope": {
+            "type": "string",
+            "enum": [
+                "recipes:read",
+                "recipes:write",
+                "ingredients:read",
+                "ingredients:write",
+                "planner:read",
+                "planner:write",
+                "admin:generate",
+                "admin:users",
+                "admin:ingredients"
+            ],
+            "x-enum-varnames": [
+                "ScopeRecipesRead",
+                "ScopeRecipesWrite",
+                "ScopeIngredientsRead",
+                "ScopeIngredientsWrite",
+                "ScopePlannerRead",
+                "ScopePlannerWrite",
+                "ScopeAdminGenerate",
+                "ScopeAdminUsers",
+                "ScopeAdminIngredients"
+            ]
+        },
+        "model.SearchHighlight": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ShoppingList": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "aisles": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.IngredientMeta"
+                        "$ref": "#/definitions/model.ShoppingListAisle"
                     }
                 },
-                "name": {
+                "created_at": {
                     "type": "string"
                 },
-                "steps": {
+                "deleted_recipes": {
+                    "description": "DeletedRecipes are the IDs of meal plan recipes that were deleted.\nTheir ingredients are not on the list.",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "meal_plan_id": {
+                    "description": "MealPlanID is set on lists made from a meal plan.",
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ShoppingListRecipe"
+                    }
+                },
+                "unresolved": {
+                    "description": "Unresolved lists the recipe lines whose ingredient was deleted. They\nare not on the list, so it is not complete.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ShoppingListGap"
+                    }
                 }
             }
         },
-        "model.RecipeWithoutID": {
+        "model.ShoppingListAisle": {
             "type": "object",
             "properties": {
-                "category": {
-                    "$ref": "#/definitions/model.Category"
+                "aisle": {
+                    "type": "string"
                 },
-                "description": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ShoppingListItem"
+                    }
+                }
+            }
+        },
+        "model.ShoppingListGap": {
+            "type": "object",
+            "properties": {
+                "ingredient_id": {
                     "type": "string"
                 },
-                "ingredients": {
+                "quantity": {
+                    "type": "number"
+                },
+                "recipe_id": {
+                    "type": "string"
+                },
+                "recipe_name": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ShoppingListItem": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ingredient": {
+                    "$ref": "#/definitions/model.Ingredient"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ShoppingListItemUpdate": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.ShoppingListRecipe": {
+            "type": "object",
+            "properties": {
+                "multiplier": {
+                    "type": "number"
+                },
+                "recipe_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ShoppingListRequest": {
+            "type": "object",
+            "properties": {
+                "recipes": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/model.ShoppingListRecipe"
+                    }
+                }
+            }
+        },
+        "model.StepChange": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "$ref": "#/definitions/model.ChangeKind"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "step": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Substitution": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "ingredient": {
+                    "type": "string"
+                },
+                "note": {
+                    "description": "Note says when the substitution works, like \"baking only\".",
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "replacements": {
+                    "description": "Replacements are the ingredients used instead, together.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Replacement"
+                    }
+                }
+            }
+        },
+        "model.SubstitutionReason": {
+            "type": "string",
+            "enum": [
+                "missing",
+                "allergen",
+                "diet"
+            ],
+            "x-enum-varnames": [
+                "ReasonMissing",
+                "ReasonAllergen",
+                "ReasonDiet"
+            ]
+        },
+        "model.SubstitutionWithoutID": {
+            "type": "object",
+            "properties": {
+                "ingredient": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "replacements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Replacement"
                     }
+                }
+            }
+        },
+        "model.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/model.UserInfo"
+                }
+            }
+        },
+        "model.TrashedRecipe": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "$ref": "#/definitions/model.Category"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
                 },
-                "ingredients_meta": {
+                "ingredient_lines": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.IngredientMeta"
+                        "$ref": "#/definitions/model.IngredientLine"
                     }
                 },
                 "name": {
                     "type": "string"
                 },
+                "owner_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer"
+                },
                 "steps": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.UnreplacedIngredient": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "$ref": "#/definitions/model.ResolvedIngredientLine"
+                },
+                "omitted": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "$ref": "#/definitions/model.SubstitutionReason"
+                }
+            }
+        },
+        "model.UserInfo": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/model.Role"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.ErrorResponse": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "rest.IngredientInUseResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "pantry_items": {
+                    "type": "integer"
+                },
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RecipeSummary"
+                    }
+                },
+                "substitutions": {
+                    "type": "integer"
                 }
             }
         }
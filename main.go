@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"rest/database"
 	_ "rest/docs"
 
 	"rest/rest"
@@ -23,7 +26,32 @@ import (
 // @host localhost:4000
 // @BasePath /
 func main() {
-	app := rest.New()
+	config := rest.LoadConfig()
+	if len(os.Args) > 1 {
+		if err := runCommand(openStore(config), os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	app := rest.New(openStore(config), config)
 	fmt.Println("starting server")
 	http.ListenAndServe(":4000", app.Router)
 }
+
+func openStore(config rest.Config) database.Store {
+	switch config.Storage {
+	case "mongo":
+		return database.Connect()
+	case "bolt":
+		store, err := database.OpenBolt(config.BoltPath)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", config.BoltPath, err)
+		}
+		return store
+	case "memory":
+		return database.NewMemoryStore()
+	default:
+		log.Fatalf("unknown STORAGE %q, expected mongo, bolt or memory", config.Storage)
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scope is a permission to call a group of routes. Roles grant scopes to
+// users, and API keys are limited to the scopes they were created with.
+type Scope string
+
+const (
+	ScopeRecipesRead      Scope = "recipes:read"
+	ScopeRecipesWrite     Scope = "recipes:write"
+	ScopeIngredientsRead  Scope = "ingredients:read"
+	ScopeIngredientsWrite Scope = "ingredients:write"
+	// The planner scopes cover shopping lists, meal plans and the pantry.
+	ScopePlannerRead   Scope = "planner:read"
+	ScopePlannerWrite  Scope = "planner:write"
+	ScopeAdminGenerate Scope = "admin:generate"
+	ScopeAdminUsers    Scope = "admin:users"
+	// ScopeAdminIngredients covers tidying up the ingredient catalog, like
+	// merging duplicates.
+	ScopeAdminIngredients Scope = "admin:ingredients"
+)
+
+var AllScopes = []Scope{
+	ScopeRecipesRead, ScopeRecipesWrite, ScopeIngredientsRead, ScopeIngredientsWrite,
+	ScopePlannerRead, ScopePlannerWrite, ScopeAdminGenerate, ScopeAdminUsers,
+	ScopeAdminIngredients,
+}
+
+func (s Scope) IsValid() bool {
+	for _, scope := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultAPIKeyRateLimit is the number of requests per minute a key may
+// make when none is given.
+const DefaultAPIKeyRateLimit = 60
+
+// APIKey is a credential for machine clients. Only a hash of the key is
+// stored; the key itself is shown once, when it is created. Requests made
+// with a key act on behalf of its owner, limited to the key's scopes.
+type APIKey struct {
+	APIKeyInfo `bson:",inline"`
+	KeyHash    string `json:"key_hash"`
+}
+
+// APIKeyInfo is what handlers show of a key.
+type APIKeyInfo struct {
+	ID string `json:"_id" bson:"_id"`
+	// Prefix is the start of the key, so users can tell their keys apart.
+	Prefix  string  `json:"prefix"`
+	Name    string  `json:"name"`
+	Scopes  []Scope `json:"scopes"`
+	OwnerID string  `json:"owner_id"`
+	// RateLimit is the number of requests per minute the key may make.
+	RateLimit  int        `json:"rate_limit"`
+	Usage      int64      `json:"usage"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+type APIKeyRequest struct {
+	Name      string  `json:"name"`
+	Scopes    []Scope `json:"scopes"`
+	RateLimit int     `json:"rate_limit"`
+}
+
+func (k *APIKeyRequest) Validate() error {
+	if k.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(k.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range k.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	if k.RateLimit < 0 {
+		return errors.New("rate_limit must not be negative")
+	}
+	if k.RateLimit == 0 {
+		k.RateLimit = DefaultAPIKeyRateLimit
+	}
+	return nil
+}
+
+// CreatedAPIKey is the response to creating a key, the only one that
+// includes the key itself.
+type CreatedAPIKey struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
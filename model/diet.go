@@ -0,0 +1,150 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Allergen is a common food allergen, following the allergens that food
+// labels have to declare.
+type Allergen string
+
+const (
+	AllergenGluten    Allergen = "gluten"
+	AllergenDairy     Allergen = "dairy"
+	AllergenEgg       Allergen = "egg"
+	AllergenNuts      Allergen = "nuts"
+	AllergenPeanuts   Allergen = "peanuts"
+	AllergenSoy       Allergen = "soy"
+	AllergenFish      Allergen = "fish"
+	AllergenShellfish Allergen = "shellfish"
+	AllergenMolluscs  Allergen = "molluscs"
+	AllergenSesame    Allergen = "sesame"
+	AllergenCelery    Allergen = "celery"
+	AllergenMustard   Allergen = "mustard"
+	AllergenLupin     Allergen = "lupin"
+	AllergenSulphites Allergen = "sulphites"
+)
+
+var AllAllergen = []Allergen{
+	AllergenGluten,
+	AllergenDairy,
+	AllergenEgg,
+	AllergenNuts,
+	AllergenPeanuts,
+	AllergenSoy,
+	AllergenFish,
+	AllergenShellfish,
+	AllergenMolluscs,
+	AllergenSesame,
+	AllergenCelery,
+	AllergenMustard,
+	AllergenLupin,
+	AllergenSulphites,
+}
+
+func (a Allergen) IsValid() bool {
+	return slices.Contains(AllAllergen, a)
+}
+
+// Diet is a diet an ingredient or recipe is suitable for.
+type Diet string
+
+const (
+	DietVegan       Diet = "vegan"
+	DietVegetarian  Diet = "vegetarian"
+	DietPescatarian Diet = "pescatarian"
+)
+
+// AllDiet lists the diets from the strictest to the loosest: food that is
+// suitable for a diet is suitable for every diet after it as well.
+var AllDiet = []Diet{
+	DietVegan,
+	DietVegetarian,
+	DietPescatarian,
+}
+
+func (d Diet) IsValid() bool {
+	return slices.Contains(AllDiet, d)
+}
+
+// FitsDiet reports whether the ingredient is suitable for diet, because it
+// is marked with that diet or a stricter one.
+func (i *Ingredient) FitsDiet(diet Diet) bool {
+	limit := slices.Index(AllDiet, diet)
+	for _, marked := range i.Diets {
+		if index := slices.Index(AllDiet, marked); index >= 0 && index <= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllergen reports whether the ingredient contains allergen, or may
+// contain it because its allergens are not known.
+func (i *Ingredient) HasAllergen(allergen Allergen) bool {
+	return i.Allergens == nil || slices.Contains(i.Allergens, allergen)
+}
+
+func validateDietary(allergens []Allergen, diets []Diet) error {
+	for _, allergen := range allergens {
+		if !allergen.IsValid() {
+			return fmt.Errorf("invalid allergen %q", allergen)
+		}
+	}
+	for _, diet := range diets {
+		if !diet.IsValid() {
+			return fmt.Errorf("invalid diet %q", diet)
+		}
+	}
+	return nil
+}
+
+// RecipeClassification is what a recipe's ingredients say about its
+// allergens and the diets it suits. It is computed from the ingredients
+// rather than taken from the recipe's description.
+type RecipeClassification struct {
+	// Allergens are the allergens of all ingredients, optional ones
+	// included.
+	Allergens []Allergen `json:"allergens"`
+	// Diets are the diets every ingredient is suitable for.
+	Diets []Diet `json:"diets"`
+	// Unclassified names the ingredients whose allergens are not known.
+	// While there are any, the recipe may contain allergens that are not in
+	// Allergens.
+	Unclassified []string `json:"unclassified,omitempty"`
+	// Unresolved counts the lines whose ingredient no longer exists. While
+	// there are any, the recipe may contain allergens that are not in
+	// Allergens, and it fits no diet.
+	Unresolved int `json:"unresolved,omitempty"`
+}
+
+// ClassifyRecipe works out the classification of a recipe from its
+// resolved ingredient lines and the number of lines that could not be
+// resolved.
+func ClassifyRecipe(lines []ResolvedIngredientLine, unresolved int) RecipeClassification {
+	classification := RecipeClassification{Allergens: []Allergen{}, Diets: []Diet{}, Unresolved: unresolved}
+	for _, allergen := range AllAllergen {
+		for _, line := range lines {
+			if slices.Contains(line.Ingredient.Allergens, allergen) {
+				classification.Allergens = append(classification.Allergens, allergen)
+				break
+			}
+		}
+	}
+	for _, diet := range AllDiet {
+		fits := unresolved == 0
+		for _, line := range lines {
+			fits = fits && line.Ingredient.FitsDiet(diet)
+		}
+		if fits {
+			classification.Diets = append(classification.Diets, diet)
+		}
+	}
+	for _, line := range lines {
+		if line.Ingredient.Allergens == nil && !slices.Contains(classification.Unclassified, line.Ingredient.Name) {
+			classification.Unclassified = append(classification.Unclassified, line.Ingredient.Name)
+		}
+	}
+	return classification
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFitsDiet(t *testing.T) {
+	tests := []struct {
+		diets []Diet
+		diet  Diet
+		want  bool
+	}{
+		{[]Diet{DietVegan}, DietVegan, true},
+		{[]Diet{DietVegan}, DietVegetarian, true},
+		{[]Diet{DietVegan}, DietPescatarian, true},
+		{[]Diet{DietVegetarian}, DietVegan, false},
+		{[]Diet{DietVegetarian}, DietPescatarian, true},
+		{[]Diet{DietPescatarian}, DietVegetarian, false},
+		{nil, DietPescatarian, false},
+	}
+	for _, test := range tests {
+		ingredient := Ingredient{Diets: test.diets}
+		if got := ingredient.FitsDiet(test.diet); got != test.want {
+			t.Errorf("Ingredient{Diets: %q}.FitsDiet(%q) = %v, want %v", test.diets, test.diet, got, test.want)
+		}
+	}
+}
+
+func TestHasAllergen(t *testing.T) {
+	tests := []struct {
+		allergens []Allergen
+		want      bool
+	}{
+		{[]Allergen{AllergenDairy}, true},
+		{[]Allergen{AllergenGluten}, false},
+		{[]Allergen{}, false},
+		// Allergens that are not known may be any of them.
+		{nil, true},
+	}
+	for _, test := range tests {
+		ingredient := Ingredient{Allergens: test.allergens}
+		if got := ingredient.HasAllergen(AllergenDairy); got != test.want {
+			t.Errorf("Ingredient{Allergens: %q}.HasAllergen(dairy) = %v, want %v", test.allergens, got, test.want)
+		}
+	}
+}
+
+func TestClassifyRecipe(t *testing.T) {
+	bread := Ingredient{Name: "bread", Allergens: []Allergen{AllergenGluten}, Diets: []Diet{DietVegan}}
+	butter := Ingredient{Name: "butter", Allergens: []Allergen{AllergenDairy}, Diets: []Diet{DietVegetarian}}
+	anchovies := Ingredient{Name: "anchovies", Allergens: []Allergen{AllergenFish}, Diets: []Diet{DietPescatarian}}
+	mystery := Ingredient{Name: "mystery sauce", Diets: []Diet{DietVegan}}
+	line := func(ingredient Ingredient) ResolvedIngredientLine {
+		return ResolvedIngredientLine{Ingredient: ingredient}
+	}
+
+	tests := []struct {
+		name         string
+		lines        []ResolvedIngredientLine
+		unresolved   int
+		allergens    []Allergen
+		diets        []Diet
+		unclassified []string
+	}{
+		{
+			name:      "no ingredients",
+			allergens: []Allergen{},
+			diets:     []Diet{DietVegan, DietVegetarian, DietPescatarian},
+		},
+		{
+			name:      "vegan",
+			lines:     []ResolvedIngredientLine{line(bread)},
+			allergens: []Allergen{AllergenGluten},
+			diets:     []Diet{DietVegan, DietVegetarian, DietPescatarian},
+		},
+		{
+			name:      "the strictest ingredient decides",
+			lines:     []ResolvedIngredientLine{line(bread), line(butter)},
+			allergens: []Allergen{AllergenGluten, AllergenDairy},
+			diets:     []Diet{DietVegetarian, DietPescatarian},
+		},
+		{
+			name:      "no common diet",
+			lines:     []ResolvedIngredientLine{line(butter), line(anchovies)},
+			allergens: []Allergen{AllergenDairy, AllergenFish},
+			diets:     []Diet{DietPescatarian},
+		},
+		{
+			name:         "unknown allergens",
+			lines:        []ResolvedIngredientLine{line(bread), line(mystery), line(mystery)},
+			allergens:    []Allergen{AllergenGluten},
+			diets:        []Diet{DietVegan, DietVegetarian, DietPescatarian},
+			unclassified: []string{"mystery sauce"},
+		},
+		{
+			name:       "unresolved lines fit no diet",
+			lines:      []ResolvedIngredientLine{line(bread)},
+			unresolved: 1,
+			allergens:  []Allergen{AllergenGluten},
+			diets:      []Diet{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ClassifyRecipe(test.lines, test.unresolved)
+			if !slices.Equal(got.Allergens, test.allergens) {
+				t.Errorf("Allergens = %q, want %q", got.Allergens, test.allergens)
+			}
+			if !slices.Equal(got.Diets, test.diets) {
+				t.Errorf("Diets = %q, want %q", got.Diets, test.diets)
+			}
+			if !slices.Equal(got.Unclassified, test.unclassified) {
+				t.Errorf("Unclassified = %q, want %q", got.Unclassified, test.unclassified)
+			}
+			if got.Unresolved != test.unresolved {
+				t.Errorf("Unresolved = %d, want %d", got.Unresolved, test.unresolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IngredientLine is one line of a recipe's ingredient list. An ingredient
+// may be on several lines, for example once for the dough and once for the
+// filling.
+type IngredientLine struct {
+	Ingredient     primitive.ObjectID `json:"ingredient"`
+	IngredientMeta `bson:",inline"`
+	// Note says how the ingredient is prepared, like "finely chopped".
+	Note     string `json:"note,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+	// Group is the heading the line is listed under, like "For the sauce".
+	Group string `json:"group,omitempty"`
+}
+
+// ResolvedIngredientLine is an IngredientLine with the full ingredient.
+type ResolvedIngredientLine struct {
+	Ingredient     Ingredient `json:"ingredient"`
+	IngredientMeta `bson:",inline"`
+	Note           string `json:"note,omitempty"`
+	Optional       bool   `json:"optional,omitempty"`
+	Group          string `json:"group,omitempty"`
+}
+
+// Resolve returns the line with ingredient in place of its ID.
+func (l *IngredientLine) Resolve(ingredient Ingredient) ResolvedIngredientLine {
+	return ResolvedIngredientLine{
+		Ingredient:     ingredient,
+		IngredientMeta: l.IngredientMeta,
+		Note:           l.Note,
+		Optional:       l.Optional,
+		Group:          l.Group,
+	}
+}
+
+// IngredientIDs returns the ingredients of lines in order, once each.
+func IngredientIDs(lines []IngredientLine) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(lines))
+	IDs := make([]primitive.ObjectID, 0, len(lines))
+	for _, line := range lines {
+		if !seen[line.Ingredient] {
+			seen[line.Ingredient] = true
+			IDs = append(IDs, line.Ingredient)
+		}
+	}
+	return IDs
+}
+
+// UsesIngredient reports whether any of lines is for the ingredient.
+func UsesIngredient(lines []IngredientLine, ingredientID primitive.ObjectID) bool {
+	for _, line := range lines {
+		if line.Ingredient == ingredientID {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyIngredients are the parallel arrays recipes had before ingredient
+// lines, joined by index. They are still accepted in requests that have no
+// ingredient_lines and included in responses, so older clients keep
+// working.
+type legacyIngredients struct {
+	Ingredients     []primitive.ObjectID `json:"ingredients"`
+	IngredientsMeta []IngredientMeta     `json:"ingredients_meta"`
+}
+
+// LinesFromLegacy turns the parallel ingredient arrays into ingredient
+// lines.
+func LinesFromLegacy(ingredients []primitive.ObjectID, metas []IngredientMeta) ([]IngredientLine, error) {
+	if len(ingredients) != len(metas) {
+		return nil, fmt.Errorf("ingredients and ingredients_meta must have the same length, got %d and %d", len(ingredients), len(metas))
+	}
+	lines := make([]IngredientLine, len(ingredients))
+	for i, ID := range ingredients {
+		lines[i] = IngredientLine{Ingredient: ID, IngredientMeta: metas[i]}
+	}
+	return lines, nil
+}
+
+func legacyFromLines(lines []IngredientLine) legacyIngredients {
+	legacy := legacyIngredients{
+		Ingredients:     make([]primitive.ObjectID, len(lines)),
+		IngredientsMeta: make([]IngredientMeta, len(lines)),
+	}
+	for i, line := range lines {
+		legacy.Ingredients[i] = line.Ingredient
+		legacy.IngredientsMeta[i] = line.IngredientMeta
+	}
+	return legacy
+}
+
+// readLegacyIngredients fills in lines from the legacy arrays in data when
+// data has no ingredient lines of its own.
+func readLegacyIngredients(data []byte, lines *[]IngredientLine) error {
+	if *lines != nil {
+		return nil
+	}
+	var legacy legacyIngredients
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	if legacy.Ingredients == nil && legacy.IngredientsMeta == nil {
+		return nil
+	}
+	converted, err := LinesFromLegacy(legacy.Ingredients, legacy.IngredientsMeta)
+	if err != nil {
+		return err
+	}
+	*lines = converted
+	return nil
+}
+
+func (r Recipe) MarshalJSON() ([]byte, error) {
+	type recipe Recipe
+	return json.Marshal(struct {
+		recipe
+		legacyIngredients
+	}{recipe(r), legacyFromLines(r.IngredientLines)})
+}
+
+func (r *Recipe) UnmarshalJSON(data []byte) error {
+	type recipe Recipe
+	if err := json.Unmarshal(data, (*recipe)(r)); err != nil {
+		return err
+	}
+	return readLegacyIngredients(data, &r.IngredientLines)
+}
+
+func (r RecipeWithoutID) MarshalJSON() ([]byte, error) {
+	type recipe RecipeWithoutID
+	return json.Marshal(struct {
+		recipe
+		legacyIngredients
+	}{recipe(r), legacyFromLines(r.IngredientLines)})
+}
+
+func (r *RecipeWithoutID) UnmarshalJSON(data []byte) error {
+	type recipe RecipeWithoutID
+	if err := json.Unmarshal(data, (*recipe)(r)); err != nil {
+		return err
+	}
+	return readLegacyIngredients(data, &r.IngredientLines)
+}
+
+func (r TrashedRecipe) MarshalJSON() ([]byte, error) {
+	type recipe TrashedRecipe
+	return json.Marshal(struct {
+		recipe
+		legacyIngredients
+	}{recipe(r), legacyFromLines(r.IngredientLines)})
+}
+
+func (r *TrashedRecipe) UnmarshalJSON(data []byte) error {
+	type recipe TrashedRecipe
+	if err := json.Unmarshal(data, (*recipe)(r)); err != nil {
+		return err
+	}
+	return readLegacyIngredients(data, &r.IngredientLines)
+}
+
+func (r ResolvedRecipe) MarshalJSON() ([]byte, error) {
+	type recipe ResolvedRecipe
+	ingredients := make([]Ingredient, len(r.IngredientLines))
+	metas := make([]IngredientMeta, len(r.IngredientLines))
+	for i, line := range r.IngredientLines {
+		ingredients[i] = line.Ingredient
+		metas[i] = line.IngredientMeta
+	}
+	return json.Marshal(struct {
+		recipe
+		Ingredients     []Ingredient     `json:"ingredients"`
+		IngredientsMeta []IngredientMeta `json:"ingredients_meta"`
+	}{recipe(r), ingredients, metas})
+}
+
+// ParseIngredientsRequest is free text to read as ingredient lines, one
+// line each. A line ending in a colon, like "For the sauce:", is the group
+// heading of the lines after it.
+type ParseIngredientsRequest struct {
+	Lines []string `json:"lines"`
+	// CreateMissing adds ingredients that match no existing one, instead
+	// of reporting the line as unmatched.
+	CreateMissing bool `json:"create_missing,omitempty"`
+}
+
+type IngredientMatch string
+
+const (
+	MatchExact   IngredientMatch = "exact"
+	MatchFuzzy   IngredientMatch = "fuzzy"
+	MatchCreated IngredientMatch = "created"
+)
+
+// ParsedIngredientLine is a line of text read as an ingredient line. Lines
+// that could not be read or matched to an ingredient have an Error.
+type ParsedIngredientLine struct {
+	Text string `json:"text"`
+	Name string `json:"name,omitempty"`
+	IngredientMeta
+	Note       string          `json:"note,omitempty"`
+	Optional   bool            `json:"optional,omitempty"`
+	Group      string          `json:"group,omitempty"`
+	Ingredient *Ingredient     `json:"ingredient,omitempty"`
+	Match      IngredientMatch `json:"match,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// Line returns the ingredient line for a matched line.
+func (p *ParsedIngredientLine) Line() (IngredientLine, error) {
+	if p.Ingredient == nil {
+		return IngredientLine{}, fmt.Errorf("%q: %s", p.Text, p.Error)
+	}
+	ID, err := primitive.ObjectIDFromHex(p.Ingredient.ID)
+	if err != nil {
+		return IngredientLine{}, err
+	}
+	return IngredientLine{Ingredient: ID, IngredientMeta: p.IngredientMeta, Note: p.Note, Optional: p.Optional, Group: p.Group}, nil
+}
+
+// IngredientsText lets a new recipe list its ingredients as free text
+// instead of ingredient lines, see ParseIngredientsRequest.
+type IngredientsText struct {
+	IngredientsText []string `json:"ingredients_text,omitempty"`
+	// CreateMissingIngredients adds ingredients the text names that do not
+	// exist yet.
+	CreateMissingIngredients bool `json:"create_missing_ingredients,omitempty"`
+}
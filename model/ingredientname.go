@@ -0,0 +1,121 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"rest/search"
+	"sort"
+	"strings"
+)
+
+// Names returns the canonical name of the ingredient followed by its
+// aliases.
+func (i *Ingredient) Names() []string {
+	return append([]string{i.Name}, i.Aliases...)
+}
+
+// HasName reports whether name is the ingredient's name or one of its
+// aliases, ignoring case, punctuation and plurals.
+func (i *Ingredient) HasName(name string) bool {
+	key := search.NameKey(name)
+	for _, own := range i.Names() {
+		if search.NameKey(own) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// FindIngredientByName returns the ingredient that has name, preferring an
+// exact match of the canonical name, see Ingredient.HasName.
+func FindIngredientByName(ingredients []*Ingredient, name string) *Ingredient {
+	for _, ingredient := range ingredients {
+		if ingredient.Name == name {
+			return ingredient
+		}
+	}
+	for _, ingredient := range ingredients {
+		if ingredient.HasName(name) {
+			return ingredient
+		}
+	}
+	return nil
+}
+
+func validateAliases(name string, aliases []string) error {
+	keys := map[string]bool{search.NameKey(name): true}
+	for _, alias := range aliases {
+		if strings.TrimSpace(alias) == "" {
+			return errors.New("aliases must not be empty")
+		}
+		key := search.NameKey(alias)
+		if keys[key] {
+			return fmt.Errorf("alias %q repeats the name or another alias", alias)
+		}
+		keys[key] = true
+	}
+	return nil
+}
+
+// AddedIngredient is a new ingredient, with a warning when its name is close
+// to that of an ingredient that already existed, which may be the same one.
+type AddedIngredient struct {
+	Ingredient
+	Warning    string                `json:"warning,omitempty"`
+	DidYouMean []IngredientCandidate `json:"did_you_mean,omitempty"`
+}
+
+// IngredientCandidate is an ingredient whose name or alias is close to a
+// name that was looked up.
+type IngredientCandidate struct {
+	Ingredient *Ingredient `json:"ingredient"`
+	// MatchedName is the name or alias that is closest.
+	MatchedName string `json:"matched_name"`
+	// Distance is the number of edits between the names, 0 when they are
+	// the same but for case and plurals.
+	Distance int `json:"distance"`
+}
+
+// RankIngredients returns the ingredients with a name or alias close to
+// name, closest first, at most limit of them. A name also comes close to
+// a longer one that has one of its words, like "parmesan" to "parmesan
+// cheese"; the other words count as one more edit in Distance.
+func RankIngredients(name string, ingredients []*Ingredient, limit int) []IngredientCandidate {
+	key := search.NameKey(name)
+	if key == "" {
+		return []IngredientCandidate{}
+	}
+	maxTypos := search.MaxTypos(key)
+	candidates := []IngredientCandidate{}
+	for _, ingredient := range ingredients {
+		best, bestTypos := IngredientCandidate{Distance: -1}, 0
+		for _, own := range ingredient.Names() {
+			ownKey := search.NameKey(own)
+			distance := search.Distance(key, ownKey)
+			typos := distance
+			if words := strings.Fields(ownKey); len(words) > 1 {
+				for _, word := range words {
+					if wordTypos := search.Distance(key, word); wordTypos+1 < distance {
+						distance, typos = wordTypos+1, wordTypos
+					}
+				}
+			}
+			if best.Distance < 0 || distance < best.Distance {
+				best, bestTypos = IngredientCandidate{Ingredient: ingredient, MatchedName: own, Distance: distance}, typos
+			}
+		}
+		if best.Distance >= 0 && bestTypos <= maxTypos {
+			candidates = append(candidates, best)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].Ingredient.Name < candidates[j].Ingredient.Name
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
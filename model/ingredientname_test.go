@@ -0,0 +1,89 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+var namedIngredients = []*Ingredient{
+	{ID: "1", Name: "tomatoes"},
+	{ID: "2", Name: "spring onions", Aliases: []string{"scallions", "green onions"}},
+	{ID: "3", Name: "black pepper"},
+	{ID: "4", Name: "parmesan cheese"},
+	{ID: "5", Name: "egg"},
+	{ID: "6", Name: "eggs"},
+}
+
+func TestFindIngredientByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"tomatoes", "1"},
+		{"Tomato", "1"},
+		{"TOMATOES!", "1"},
+		{"scallion", "2"},
+		{"Green Onions", "2"},
+		{"spring onions", "2"},
+		// An exact name wins over one that only matches when folded.
+		{"eggs", "6"},
+		{"egg", "5"},
+		{"pepper", ""},
+		{"tomatos", "1"},
+		{"tomatillo", ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if ingredient := FindIngredientByName(namedIngredients, test.name); ingredient != nil {
+			got = ingredient.ID
+		}
+		if got != test.want {
+			t.Errorf("FindIngredientByName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRankIngredients(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"tomato", 5, []string{"tomatoes"}},
+		{"tomatos", 5, []string{"tomatoes"}},
+		{"scalions", 5, []string{"spring onions"}},
+		{"parmesan", 5, []string{"parmesan cheese"}},
+		{"peper", 5, []string{"black pepper"}},
+		{"blak peper", 5, []string{"black pepper"}},
+		{"eg", 5, []string{}},
+		{"saffron", 5, []string{}},
+		{"", 5, []string{}},
+		{"egs", 5, []string{}},
+		{"eggz", 5, []string{"egg", "eggs"}},
+		{"eggz", 1, []string{"egg"}},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, candidate := range RankIngredients(test.name, namedIngredients, test.limit) {
+			got = append(got, candidate.Ingredient.Name)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("RankIngredients(%q, %d) = %q, want %q", test.name, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestRankIngredientsDistance(t *testing.T) {
+	candidates := RankIngredients("scalions", namedIngredients, 5)
+	if len(candidates) != 1 {
+		t.Fatalf("RankIngredients(%q) returned %d candidates, want 1", "scalions", len(candidates))
+	}
+	if candidates[0].MatchedName != "scallions" || candidates[0].Distance != 1 {
+		t.Errorf("RankIngredients(%q) matched %q at distance %d, want %q at 1", "scalions", candidates[0].MatchedName, candidates[0].Distance, "scallions")
+	}
+	// A word of a longer name counts one edit for the other words.
+	candidates = RankIngredients("parmesan", namedIngredients, 5)
+	if len(candidates) != 1 || candidates[0].Distance != 1 {
+		t.Errorf("RankIngredients(%q) = %+v, want parmesan cheese at distance 1", "parmesan", candidates)
+	}
+}
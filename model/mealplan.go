@@ -0,0 +1,103 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type MealSlot string
+
+const (
+	Breakfast MealSlot = "breakfast"
+	Lunch     MealSlot = "lunch"
+	Snack     MealSlot = "snack"
+	Dinner    MealSlot = "dinner"
+)
+
+// AllMealSlots lists the slots in the order they come in a day.
+var AllMealSlots = []MealSlot{Breakfast, Lunch, Snack, Dinner}
+
+func (s MealSlot) IsValid() bool {
+	return slices.Contains(AllMealSlots, s)
+}
+
+// MealPlanEntry puts a recipe on the calendar. Date is a day in YYYY-MM-DD
+// form. Servings defaults to the servings the recipe makes.
+type MealPlanEntry struct {
+	Date     string   `json:"date"`
+	Slot     MealSlot `json:"slot"`
+	RecipeID string   `json:"recipe_id"`
+	Servings int      `json:"servings,omitempty"`
+}
+
+// Day parses the entry's date.
+func (e MealPlanEntry) Day() (time.Time, error) {
+	return time.Parse(time.DateOnly, e.Date)
+}
+
+type MealPlan struct {
+	ID      string          `json:"_id" bson:"_id"`
+	Name    string          `json:"name"`
+	Entries []MealPlanEntry `json:"entries"`
+	OwnerID string          `json:"owner_id,omitempty"`
+}
+
+type MealPlanWithoutID struct {
+	Name    string          `json:"name"`
+	Entries []MealPlanEntry `json:"entries"`
+	// OwnerID is set from the caller; the one in a request body is ignored.
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+// Validate checks that a meal plan can be stored and sorts its entries by
+// date and slot.
+func (p *MealPlanWithoutID) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if p.Entries == nil {
+		p.Entries = []MealPlanEntry{}
+	}
+	for i, entry := range p.Entries {
+		if _, err := entry.Day(); err != nil {
+			return fmt.Errorf("entries[%d]: date must be YYYY-MM-DD", i)
+		}
+		if !entry.Slot.IsValid() {
+			return fmt.Errorf("entries[%d]: invalid slot %q", i, entry.Slot)
+		}
+		if entry.RecipeID == "" {
+			return fmt.Errorf("entries[%d]: recipe_id is required", i)
+		}
+		if entry.Servings < 0 {
+			return fmt.Errorf("entries[%d]: servings must not be negative", i)
+		}
+	}
+	SortMealPlanEntries(p.Entries)
+	return nil
+}
+
+// WithID returns the meal plan stored under ID.
+func (p *MealPlanWithoutID) WithID(ID string) *MealPlan {
+	return &MealPlan{ID: ID, Name: p.Name, Entries: p.Entries, OwnerID: p.OwnerID}
+}
+
+// SortMealPlanEntries orders entries by date, then by slot.
+func SortMealPlanEntries(entries []MealPlanEntry) {
+	slices.SortStableFunc(entries, func(a, b MealPlanEntry) int {
+		if a.Date != b.Date {
+			if a.Date < b.Date {
+				return -1
+			}
+			return 1
+		}
+		return slices.Index(AllMealSlots, a.Slot) - slices.Index(AllMealSlots, b.Slot)
+	})
+}
+
+// CopyWeekRequest names the week to copy by any date in it. Weeks start on
+// Monday.
+type CopyWeekRequest struct {
+	Week string `json:"week"`
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"rest/search"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MergeIngredientsRequest names the duplicates to merge into an ingredient.
+type MergeIngredientsRequest struct {
+	Duplicates []string `json:"duplicates"`
+	// DryRun only reports what merging would change.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// IngredientMerge is what merging duplicates into an ingredient changes.
+type IngredientMerge struct {
+	// Ingredient is the ingredient the duplicates were merged into, as it
+	// is after the merge.
+	Ingredient *Ingredient `json:"ingredient"`
+	// Merged are the duplicates, which are deleted.
+	Merged []*Ingredient `json:"merged"`
+	// Recipes are the recipes, including those in the trash, whose lines
+	// are pointed at Ingredient.
+	Recipes       []RecipeSummary `json:"recipes"`
+	PantryItems   int             `json:"pantry_items"`
+	Substitutions int             `json:"substitutions"`
+	DryRun        bool            `json:"dry_run"`
+}
+
+// MergedIngredient returns the ingredient that results from merging the
+// duplicates into canonical. The names and aliases of the duplicates become
+// aliases, facts canonical lacks are taken from the first duplicate that
+// has them, and allergens are combined so none is lost.
+func MergedIngredient(canonical *Ingredient, duplicates []*Ingredient) IngredientWithoutID {
+	merged := canonical.WithoutID()
+	merged.Aliases = slices.Clone(merged.Aliases)
+	keys := map[string]bool{}
+	for _, name := range canonical.Names() {
+		keys[search.NameKey(name)] = true
+	}
+	for _, duplicate := range duplicates {
+		for _, name := range duplicate.Names() {
+			if key := search.NameKey(name); !keys[key] {
+				keys[key] = true
+				merged.Aliases = append(merged.Aliases, name)
+			}
+		}
+		if merged.Density == 0 {
+			merged.Density = duplicate.Density
+		}
+		if merged.Aisle == "" {
+			merged.Aisle = duplicate.Aisle
+		}
+		if merged.Nutrition == nil {
+			merged.Nutrition = duplicate.Nutrition
+		}
+		if merged.Diets == nil {
+			merged.Diets = duplicate.Diets
+		}
+		if merged.Allergens == nil {
+			merged.Allergens = duplicate.Allergens
+			continue
+		}
+		for _, allergen := range duplicate.Allergens {
+			if !slices.Contains(merged.Allergens, allergen) {
+				merged.Allergens = append(merged.Allergens, allergen)
+			}
+		}
+	}
+	return merged
+}
+
+// ReplaceIngredients points the lines for any of the duplicates at ID
+// instead. changed is false when no line is for a duplicate.
+func ReplaceIngredients(lines []IngredientLine, duplicates map[primitive.ObjectID]bool, ID primitive.ObjectID) (replaced []IngredientLine, changed bool) {
+	replaced = slices.Clone(lines)
+	for i, line := range replaced {
+		if duplicates[line.Ingredient] {
+			replaced[i].Ingredient = ID
+			changed = true
+		}
+	}
+	return replaced, changed
+}
+
+// ReplaceIngredients points the substitution at ID wherever it refers to one
+// of the duplicates. keep is false when the substitution would then replace
+// an ingredient with itself and has to be deleted.
+func (s *Substitution) ReplaceIngredients(duplicates map[primitive.ObjectID]bool, ID primitive.ObjectID) (changed bool, keep bool) {
+	if duplicates[s.Ingredient] {
+		s.Ingredient = ID
+		changed = true
+	}
+	s.Replacements = slices.Clone(s.Replacements)
+	for i, replacement := range s.Replacements {
+		if duplicates[replacement.Ingredient] {
+			s.Replacements[i].Ingredient = ID
+			changed = true
+		}
+		if s.Replacements[i].Ingredient == s.Ingredient {
+			return changed, false
+		}
+	}
+	return changed, true
+}
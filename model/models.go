@@ -1,13 +1,35 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"rest/units"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	_ "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Ingredient struct {
 	ID   string `json:"_id" bson:"_id"`
 	Name string `json:"name"`
+	// Aliases are other names the ingredient goes by, like "scallions" for
+	// spring onions. Lookups by name find an ingredient by its aliases too.
+	Aliases []string `json:"aliases,omitempty"`
+	// Density in grams per millilitre, used to convert between volume and
+	// mass. Zero when unknown.
+	Density float64 `json:"density,omitempty"`
+	// Aisle is where the ingredient is found in a store, used to group
+	// shopping lists.
+	Aisle   string `json:"aisle,omitempty"`
+	OwnerID string `json:"owner_id,omitempty"`
+	// Nutrition is per 100 g, or nil when unknown.
+	Nutrition *Nutrition `json:"nutrition,omitempty"`
+	// Allergens the ingredient contains. Nil means they are not known, an
+	// empty list that it contains none.
+	Allergens []Allergen `json:"allergens"`
+	// Diets the ingredient is suitable for. Marking it vegan is enough for
+	// vegetarian and pescatarian too.
+	Diets []Diet `json:"diets,omitempty"`
 }
 
 type IngredientMeta struct {
@@ -16,36 +38,116 @@ type IngredientMeta struct {
 }
 
 type IngredientWithoutID struct {
-	Name string `json:"name"`
+	Name      string     `json:"name"`
+	Aliases   []string   `json:"aliases,omitempty"`
+	Density   float64    `json:"density,omitempty"`
+	Aisle     string     `json:"aisle,omitempty"`
+	OwnerID   string     `json:"owner_id,omitempty"`
+	Nutrition *Nutrition `json:"nutrition,omitempty"`
+	Allergens []Allergen `json:"allergens"`
+	Diets     []Diet     `json:"diets,omitempty"`
 }
 
-type Recipe struct {
-	ID              string               `json:"_id" bson:"_id"`
-	Name            string               `json:"name"`
-	Description     string               `json:"description,omitempty"`
-	Category        Category             `json:"category"`
-	Steps           []string             `json:"steps"`
-	Ingredients     []primitive.ObjectID `json:"ingredients"`
-	IngredientsMeta []IngredientMeta     `json:"ingredients_meta"`
+// Validate checks that an ingredient can be stored.
+func (i *IngredientWithoutID) Validate() error {
+	if i.Name == "" {
+		return errors.New("name is required")
+	}
+	if i.Density < 0 {
+		return errors.New("density must not be negative")
+	}
+	if err := validateAliases(i.Name, i.Aliases); err != nil {
+		return err
+	}
+	if i.Nutrition != nil {
+		if err := i.Nutrition.Validate(); err != nil {
+			return fmt.Errorf("nutrition: %w", err)
+		}
+	}
+	return validateDietary(i.Allergens, i.Diets)
 }
 
-type ResolvedRecipe struct {
+// WithID returns the ingredient stored under ID.
+func (i *IngredientWithoutID) WithID(ID string) *Ingredient {
+	return &Ingredient{ID: ID, Name: i.Name, Aliases: i.Aliases, Density: i.Density, Aisle: i.Aisle, OwnerID: i.OwnerID, Nutrition: i.Nutrition, Allergens: i.Allergens, Diets: i.Diets}
+}
+
+// WithoutID returns the fields of the ingredient that can be written.
+func (i *Ingredient) WithoutID() IngredientWithoutID {
+	return IngredientWithoutID{Name: i.Name, Aliases: i.Aliases, Density: i.Density, Aisle: i.Aisle, OwnerID: i.OwnerID, Nutrition: i.Nutrition, Allergens: i.Allergens, Diets: i.Diets}
+}
+
+type Recipe struct {
 	ID              string           `json:"_id" bson:"_id"`
 	Name            string           `json:"name"`
 	Description     string           `json:"description,omitempty"`
 	Category        Category         `json:"category"`
+	Servings        int              `json:"servings,omitempty"`
 	Steps           []string         `json:"steps"`
-	Ingredients     []Ingredient     `json:"ingredients"`
-	IngredientsMeta []IngredientMeta `json:"ingredients_meta"`
+	IngredientLines []IngredientLine `json:"ingredient_lines"`
+	OwnerID         string           `json:"owner_id,omitempty"`
+	// Version counts the changes to the recipe, starting at 1. It is the
+	// recipe's ETag; recipes stored before it was kept are at 0.
+	Version int `json:"version"`
+}
+
+// WithoutID returns the fields of the recipe that can be written.
+func (r *Recipe) WithoutID() RecipeWithoutID {
+	return RecipeWithoutID{
+		Name:            r.Name,
+		Description:     r.Description,
+		Category:        r.Category,
+		Servings:        r.Servings,
+		Steps:           r.Steps,
+		IngredientLines: r.IngredientLines,
+		OwnerID:         r.OwnerID,
+		Version:         r.Version,
+	}
+}
+
+// RecipeSummary identifies a recipe in responses that only need to refer to
+// it.
+type RecipeSummary struct {
+	ID   string `json:"_id"`
+	Name string `json:"name"`
+}
+
+type ResolvedRecipe struct {
+	ID              string                   `json:"_id" bson:"_id"`
+	Name            string                   `json:"name"`
+	Description     string                   `json:"description,omitempty"`
+	Category        Category                 `json:"category"`
+	Servings        int                      `json:"servings,omitempty"`
+	Steps           []string                 `json:"steps"`
+	IngredientLines []ResolvedIngredientLine `json:"ingredient_lines"`
+	OwnerID         string                   `json:"owner_id,omitempty"`
+	Version         int                      `json:"version"`
+	Classification  RecipeClassification     `json:"classification"`
 }
 
 type RecipeWithoutID struct {
-	Name            string               `json:"name"`
-	Description     string               `json:"description,omitempty"`
-	Category        Category             `json:"category"`
-	Steps           []string             `json:"steps"`
-	Ingredients     []primitive.ObjectID `json:"ingredients"`
-	IngredientsMeta []IngredientMeta     `json:"ingredients_meta"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description,omitempty"`
+	Category        Category         `json:"category"`
+	Servings        int              `json:"servings,omitempty"`
+	Steps           []string         `json:"steps"`
+	IngredientLines []IngredientLine `json:"ingredient_lines"`
+	OwnerID         string           `json:"owner_id,omitempty"`
+	// Version is set by the store; the one in a request body is ignored.
+	Version int `json:"version,omitempty"`
+}
+
+type TrashedRecipe struct {
+	ID              string           `json:"_id" bson:"_id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description,omitempty"`
+	Category        Category         `json:"category"`
+	Servings        int              `json:"servings,omitempty"`
+	Steps           []string         `json:"steps"`
+	IngredientLines []IngredientLine `json:"ingredient_lines"`
+	OwnerID         string           `json:"owner_id,omitempty"`
+	Version         int              `json:"version"`
+	DeletedAt       time.Time        `json:"deleted_at"`
 }
 
 type RecipeTestData struct {
@@ -53,20 +155,88 @@ type RecipeTestData struct {
 	Name            string               `json:"name"`
 	Description     string               `json:"description,omitempty"`
 	Category        Category             `json:"category"`
+	Servings        int                  `json:"servings,omitempty"`
 	Steps           []string             `json:"steps"`
 	Ingredients     []primitive.ObjectID `json:"ingredients"`
 	IngredientsMeta []IngredientMeta     `json:"ingredients_meta"`
 }
 
-// type UpdateRecipeInput struct {
-// 	ID              string               `json:"_id"`
-// 	Name            *string              `json:"name,omitempty"`
-// 	Description     *string              `json:"description,omitempty"`
-// 	Category        *Category            `json:"category,omitempty"`
-// 	Steps           []string             `json:"steps,omitempty"`
-// 	Ingredients     []primitive.ObjectID `json:"ingredients"`
-// 	IngredientsMeta []IngredientMeta     `json:"ingredients_meta"`
-// }
+type SortField string
+
+const (
+	SortByCreated SortField = "created"
+	SortByName    SortField = "name"
+)
+
+// ListQuery selects one page of a list endpoint.
+type ListQuery struct {
+	Category   Category
+	Ingredient *primitive.ObjectID
+	// AllowedIngredients, unless nil, leaves out recipes that use any
+	// ingredient that is not one of these, including ingredients that no
+	// longer exist.
+	AllowedIngredients []primitive.ObjectID
+	SortField          SortField
+	Descending         bool
+	Limit              int
+	// After is the last item of the previous page, or nil for the first
+	// page.
+	After *ListCursor
+}
+
+// ListCursor is the position of an item in a sorted list: its sort key and
+// its ID, which breaks ties between items with the same sort key.
+type ListCursor struct {
+	Name string `json:"n,omitempty"`
+	ID   string `json:"id"`
+}
+
+type RecipePage struct {
+	Data       []*Recipe `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type IngredientPage struct {
+	Data       []*Ingredient `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+type RecipeSearchHit struct {
+	Recipe     ResolvedRecipe    `json:"recipe"`
+	Score      float64           `json:"score"`
+	Highlights []SearchHighlight `json:"highlights"`
+}
+
+type CookableQuery struct {
+	// Ingredients are the names or IDs of the ingredients on hand.
+	Ingredients []string `json:"ingredients"`
+	// MaxMissing leaves out recipes that miss more ingredients than this.
+	MaxMissing *int `json:"max_missing,omitempty"`
+}
+
+type MissingIngredient struct {
+	Ingredient Ingredient `json:"ingredient"`
+	Quantity   float64    `json:"quantity"`
+	Unit       string     `json:"unit"`
+}
+
+type CookableRecipe struct {
+	Recipe Recipe `json:"recipe"`
+	// Coverage is the share of the recipe's required ingredient lines that
+	// are on hand, from 0 to 1. Optional lines are not counted.
+	Coverage float64             `json:"coverage"`
+	Missing  []MissingIngredient `json:"missing"`
+}
+
+type CookableResponse struct {
+	Recipes            []CookableRecipe `json:"recipes"`
+	UnknownIngredients []string         `json:"unknown_ingredients"`
+}
 
 type Category string
 
@@ -83,3 +253,41 @@ var AllCategory = []Category{
 	CategoryDessert,
 	CategoryAppetizer,
 }
+
+func (c Category) IsValid() bool {
+	for _, category := range AllCategory {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that a recipe can be stored: it needs a name, a known
+// category, and an ingredient and a known unit on every ingredient line.
+// Unit names are rewritten to their canonical symbol.
+func (r *RecipeWithoutID) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if !r.Category.IsValid() {
+		return fmt.Errorf("invalid category %q", r.Category)
+	}
+	if r.Servings < 0 {
+		return errors.New("servings must not be negative")
+	}
+	for i, line := range r.IngredientLines {
+		if line.Ingredient.IsZero() {
+			return fmt.Errorf("ingredient_lines[%d]: ingredient is required", i)
+		}
+		unit, err := units.Normalize(line.Unit)
+		if err != nil {
+			return fmt.Errorf("ingredient_lines[%d]: %w", i, err)
+		}
+		if line.Quantity < 0 {
+			return fmt.Errorf("ingredient_lines[%d]: quantity must not be negative", i)
+		}
+		r.IngredientLines[i].Unit = unit
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"errors"
+	"math"
+)
+
+// Nutrition is the nutritional value of an amount of food: 100 g of an
+// ingredient, or a recipe or serving. Energy is in kcal, everything else in
+// grams.
+type Nutrition struct {
+	Energy        float64 `json:"energy"`
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Fiber         float64 `json:"fiber"`
+	Sugar         float64 `json:"sugar"`
+	Salt          float64 `json:"salt"`
+}
+
+func (n *Nutrition) Validate() error {
+	for _, value := range []float64{n.Energy, n.Protein, n.Fat, n.Carbohydrates, n.Fiber, n.Sugar, n.Salt} {
+		if value < 0 {
+			return errors.New("values must not be negative")
+		}
+	}
+	return nil
+}
+
+// Add adds factor times other to n.
+func (n *Nutrition) Add(other Nutrition, factor float64) {
+	n.Energy += other.Energy * factor
+	n.Protein += other.Protein * factor
+	n.Fat += other.Fat * factor
+	n.Carbohydrates += other.Carbohydrates * factor
+	n.Fiber += other.Fiber * factor
+	n.Sugar += other.Sugar * factor
+	n.Salt += other.Salt * factor
+}
+
+// Round rounds every value to one decimal.
+func (n *Nutrition) Round() {
+	for _, value := range []*float64{&n.Energy, &n.Protein, &n.Fat, &n.Carbohydrates, &n.Fiber, &n.Sugar, &n.Salt} {
+		*value = math.Round(*value*10) / 10
+	}
+}
+
+// RecipeNutrition is the nutritional value of a recipe, added up from its
+// ingredient lines. Lines that could not be counted are listed in Missing,
+// so a total that leaves something out is never mistaken for a full one.
+type RecipeNutrition struct {
+	Recipe   RecipeSummary `json:"recipe"`
+	Total    Nutrition     `json:"total"`
+	Servings int           `json:"servings,omitempty"`
+	// PerServing is left out when the recipe does not say how many
+	// servings it makes.
+	PerServing *Nutrition     `json:"per_serving,omitempty"`
+	Missing    []NutritionGap `json:"missing"`
+	// Complete is true when every ingredient line was counted.
+	Complete bool `json:"complete"`
+}
+
+type NutritionGapReason string
+
+const (
+	// GapNoData is an ingredient without nutrition facts.
+	GapNoData NutritionGapReason = "no_nutrition_data"
+	// GapNoQuantity is a line without an amount, like "salt to taste".
+	GapNoQuantity NutritionGapReason = "no_quantity"
+	// GapUnconvertible is an amount that cannot be expressed in grams,
+	// like a count of cloves, or a volume of an ingredient without a
+	// density.
+	GapUnconvertible NutritionGapReason = "unconvertible_unit"
+	// GapUnknownIngredient is a line whose ingredient was deleted. Its
+	// name is not known.
+	GapUnknownIngredient NutritionGapReason = "unknown_ingredient"
+)
+
+// NutritionGap is an ingredient line left out of a nutrition total.
+type NutritionGap struct {
+	IngredientID string             `json:"ingredient_id"`
+	Name         string             `json:"name"`
+	Quantity     float64            `json:"quantity"`
+	Unit         string             `json:"unit"`
+	Reason       NutritionGapReason `json:"reason"`
+}
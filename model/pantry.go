@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"rest/units"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PantryItem is an amount of an ingredient on hand. Dates are days in
+// YYYY-MM-DD form and are optional.
+type PantryItem struct {
+	ID           string             `json:"_id" bson:"_id"`
+	Ingredient   primitive.ObjectID `json:"ingredient"`
+	Quantity     float64            `json:"quantity"`
+	Unit         string             `json:"unit"`
+	PurchaseDate string             `json:"purchase_date,omitempty"`
+	ExpiryDate   string             `json:"expiry_date,omitempty"`
+	OwnerID      string             `json:"owner_id,omitempty"`
+}
+
+type PantryItemWithoutID struct {
+	Ingredient   primitive.ObjectID `json:"ingredient"`
+	Quantity     float64            `json:"quantity"`
+	Unit         string             `json:"unit"`
+	PurchaseDate string             `json:"purchase_date,omitempty"`
+	ExpiryDate   string             `json:"expiry_date,omitempty"`
+	// OwnerID is set from the caller; the one in a request body is ignored.
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+// Validate checks that a pantry item can be stored and rewrites its unit to
+// the canonical symbol.
+func (p *PantryItemWithoutID) Validate() error {
+	if p.Ingredient.IsZero() {
+		return errors.New("ingredient is required")
+	}
+	if p.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	unit, err := units.Normalize(p.Unit)
+	if err != nil {
+		return err
+	}
+	p.Unit = unit
+	if !validDate(p.PurchaseDate) {
+		return errors.New("purchase_date must be YYYY-MM-DD")
+	}
+	if !validDate(p.ExpiryDate) {
+		return errors.New("expiry_date must be YYYY-MM-DD")
+	}
+	if p.PurchaseDate != "" && p.ExpiryDate != "" && p.ExpiryDate < p.PurchaseDate {
+		return errors.New("expiry_date must not be before purchase_date")
+	}
+	return nil
+}
+
+// validDate reports whether date is empty or a day in YYYY-MM-DD form.
+func validDate(date string) bool {
+	if date == "" {
+		return true
+	}
+	_, err := time.Parse(time.DateOnly, date)
+	return err == nil
+}
+
+// WithID returns the pantry item stored under ID.
+func (p *PantryItemWithoutID) WithID(ID string) *PantryItem {
+	return &PantryItem{
+		ID:           ID,
+		Ingredient:   p.Ingredient,
+		Quantity:     p.Quantity,
+		Unit:         p.Unit,
+		PurchaseDate: p.PurchaseDate,
+		ExpiryDate:   p.ExpiryDate,
+		OwnerID:      p.OwnerID,
+	}
+}
+
+// PantryUsage is an amount taken from a pantry item.
+type PantryUsage struct {
+	ItemID     string     `json:"item_id"`
+	Ingredient Ingredient `json:"ingredient"`
+	Quantity   float64    `json:"quantity"`
+	Unit       string     `json:"unit"`
+	// Remaining is what is left of the item. Items that are used up are
+	// removed from the pantry.
+	Remaining float64 `json:"remaining"`
+}
+
+// CookedResponse lists what cooking a recipe took from the pantry, and what
+// the pantry did not have enough of.
+type CookedResponse struct {
+	Used    []PantryUsage       `json:"used"`
+	Missing []MissingIngredient `json:"missing"`
+}
+
+type ExpiringPantryItem struct {
+	PantryItem
+	IngredientName string `json:"ingredient_name"`
+	// DaysLeft is negative for items that have already expired.
+	DaysLeft int `json:"days_left"`
+}
+
+// ExpiringRecipe is a recipe that uses some of the expiring ingredients.
+type ExpiringRecipe struct {
+	Recipe RecipeSummary `json:"recipe"`
+	Uses   []Ingredient  `json:"uses"`
+}
+
+type ExpiringPantryResponse struct {
+	Items   []ExpiringPantryItem `json:"items"`
+	Recipes []ExpiringRecipe     `json:"recipes"`
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecipeRevision is a recipe as it was after one change. Revisions are
+// never changed once stored; reverting a recipe adds a new revision.
+type RecipeRevision struct {
+	ID       string `json:"_id" bson:"_id"`
+	RecipeID string `json:"recipe_id"`
+	// Number counts the revisions of a recipe, starting at 1.
+	Number     int             `json:"number"`
+	AuthorID   string          `json:"author_id,omitempty"`
+	AuthorName string          `json:"author_name,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Message    string          `json:"message,omitempty"`
+	Recipe     RecipeWithoutID `json:"recipe"`
+}
+
+// RecipeDiff is what changed in a recipe between two revisions. Fields that
+// did not change are left out.
+type RecipeDiff struct {
+	From        int                `json:"from"`
+	To          int                `json:"to"`
+	Name        *FieldChange       `json:"name,omitempty"`
+	Description *FieldChange       `json:"description,omitempty"`
+	Category    *FieldChange       `json:"category,omitempty"`
+	Servings    *FieldChange       `json:"servings,omitempty"`
+	Steps       []StepChange       `json:"steps,omitempty"`
+	Ingredients []IngredientChange `json:"ingredients,omitempty"`
+}
+
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// StepChange is a step that was added or removed. Index is the step's
+// position in the revision it appears in, starting at 0.
+type StepChange struct {
+	Change ChangeKind `json:"change"`
+	Index  int        `json:"index"`
+	Step   string     `json:"step"`
+}
+
+// IngredientChange is an ingredient line that was added, removed, or whose
+// quantity, unit, note or optional flag changed. Lines are matched by
+// ingredient and group.
+type IngredientChange struct {
+	Change       ChangeKind      `json:"change"`
+	IngredientID string          `json:"ingredient_id"`
+	Name         string          `json:"name,omitempty"`
+	Group        string          `json:"group,omitempty"`
+	From         *IngredientLine `json:"from,omitempty"`
+	To           *IngredientLine `json:"to,omitempty"`
+}
+
+// DiffRecipes compares two versions of a recipe. Ingredient names are left
+// for the caller to fill in.
+func DiffRecipes(from, to *RecipeWithoutID) RecipeDiff {
+	var diff RecipeDiff
+	if from.Name != to.Name {
+		diff.Name = &FieldChange{From: from.Name, To: to.Name}
+	}
+	if from.Description != to.Description {
+		diff.Description = &FieldChange{From: from.Description, To: to.Description}
+	}
+	if from.Category != to.Category {
+		diff.Category = &FieldChange{From: from.Category, To: to.Category}
+	}
+	if from.Servings != to.Servings {
+		diff.Servings = &FieldChange{From: from.Servings, To: to.Servings}
+	}
+	diff.Steps = diffSteps(from.Steps, to.Steps)
+	diff.Ingredients = diffIngredients(from, to)
+	return diff
+}
+
+// diffSteps finds the steps that were removed from and added to a list,
+// keeping the longest common subsequence of steps in place.
+func diffSteps(from, to []string) []StepChange {
+	// common[i][j] is the length of the longest common subsequence of
+	// from[i:] and to[j:].
+	common := make([][]int, len(from)+1)
+	for i := range common {
+		common[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	var changes []StepChange
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			i++
+			j++
+		case i < len(from) && (j == len(to) || common[i+1][j] >= common[i][j+1]):
+			changes = append(changes, StepChange{Change: ChangeRemoved, Index: i, Step: from[i]})
+			i++
+		default:
+			changes = append(changes, StepChange{Change: ChangeAdded, Index: j, Step: to[j]})
+			j++
+		}
+	}
+	return changes
+}
+
+func diffIngredients(from, to *RecipeWithoutID) []IngredientChange {
+	before := linesByKey(from.IngredientLines)
+	after := linesByKey(to.IngredientLines)
+	var changes []IngredientChange
+	for _, key := range lineKeys(from.IngredientLines) {
+		old := before[key]
+		updated, ok := after[key]
+		if !ok {
+			changes = append(changes, IngredientChange{Change: ChangeRemoved, IngredientID: key.ingredient.Hex(), Group: key.group, From: old})
+		} else if *updated != *old {
+			changes = append(changes, IngredientChange{Change: ChangeChanged, IngredientID: key.ingredient.Hex(), Group: key.group, From: old, To: updated})
+		}
+	}
+	for _, key := range lineKeys(to.IngredientLines) {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, IngredientChange{Change: ChangeAdded, IngredientID: key.ingredient.Hex(), Group: key.group, To: after[key]})
+		}
+	}
+	return changes
+}
+
+// lineKey identifies an ingredient line across revisions. occurrence tells
+// apart lines for the same ingredient in the same group.
+type lineKey struct {
+	ingredient primitive.ObjectID
+	group      string
+	occurrence int
+}
+
+func lineKeys(lines []IngredientLine) []lineKey {
+	counts := make(map[lineKey]int, len(lines))
+	keys := make([]lineKey, len(lines))
+	for i, line := range lines {
+		key := lineKey{ingredient: line.Ingredient, group: line.Group}
+		keys[i] = lineKey{ingredient: line.Ingredient, group: line.Group, occurrence: counts[key]}
+		counts[key]++
+	}
+	return keys
+}
+
+func linesByKey(lines []IngredientLine) map[lineKey]*IngredientLine {
+	byKey := make(map[lineKey]*IngredientLine, len(lines))
+	for i, key := range lineKeys(lines) {
+		byKey[key] = &lines[i]
+	}
+	return byKey
+}
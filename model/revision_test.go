@@ -0,0 +1,78 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffRecipesFields(t *testing.T) {
+	from := &RecipeWithoutID{Name: "Soup", Category: CategoryMainCourse, Servings: 2}
+	to := &RecipeWithoutID{Name: "Tomato soup", Category: CategoryMainCourse, Servings: 4}
+	diff := DiffRecipes(from, to)
+	if !reflect.DeepEqual(diff.Name, &FieldChange{From: "Soup", To: "Tomato soup"}) {
+		t.Errorf("Name = %+v", diff.Name)
+	}
+	if !reflect.DeepEqual(diff.Servings, &FieldChange{From: 2, To: 4}) {
+		t.Errorf("Servings = %+v", diff.Servings)
+	}
+	if diff.Description != nil || diff.Category != nil {
+		t.Errorf("unchanged fields in diff: %+v", diff)
+	}
+	if diff := DiffRecipes(from, from); !reflect.DeepEqual(diff, RecipeDiff{}) {
+		t.Errorf("DiffRecipes of a recipe with itself = %+v, want no changes", diff)
+	}
+}
+
+func TestDiffRecipesSteps(t *testing.T) {
+	tests := []struct {
+		from []string
+		to   []string
+		want []StepChange
+	}{
+		{[]string{"a", "b"}, []string{"a", "b"}, nil},
+		{nil, []string{"a"}, []StepChange{{ChangeAdded, 0, "a"}}},
+		{[]string{"a", "b", "c"}, []string{"a", "c"}, []StepChange{{ChangeRemoved, 1, "b"}}},
+		{[]string{"a", "c"}, []string{"a", "b", "c"}, []StepChange{{ChangeAdded, 1, "b"}}},
+		// A rewritten step is removed and added back.
+		{[]string{"a", "b", "c"}, []string{"a", "B", "c"}, []StepChange{{ChangeRemoved, 1, "b"}, {ChangeAdded, 1, "B"}}},
+		// Moving a step keeps the longest run of steps in place.
+		{[]string{"a", "b", "c"}, []string{"b", "c", "a"}, []StepChange{{ChangeRemoved, 0, "a"}, {ChangeAdded, 2, "a"}}},
+	}
+	for _, test := range tests {
+		diff := DiffRecipes(&RecipeWithoutID{Steps: test.from}, &RecipeWithoutID{Steps: test.to})
+		if !reflect.DeepEqual(diff.Steps, test.want) {
+			t.Errorf("steps %q to %q = %+v, want %+v", test.from, test.to, diff.Steps, test.want)
+		}
+	}
+}
+
+func TestDiffRecipesIngredients(t *testing.T) {
+	flour := primitive.NewObjectID()
+	salt := primitive.NewObjectID()
+	sugar := primitive.NewObjectID()
+	line := func(ingredient primitive.ObjectID, quantity float64, group string) IngredientLine {
+		return IngredientLine{Ingredient: ingredient, IngredientMeta: IngredientMeta{Quantity: quantity, Unit: "g"}, Group: group}
+	}
+	from := &RecipeWithoutID{IngredientLines: []IngredientLine{
+		line(flour, 200, ""),
+		line(salt, 5, ""),
+		line(sugar, 50, "Dough"),
+		line(sugar, 20, "Topping"),
+	}}
+	to := &RecipeWithoutID{IngredientLines: []IngredientLine{
+		line(flour, 250, ""),
+		line(sugar, 50, "Dough"),
+		line(sugar, 20, "Topping"),
+		line(sugar, 10, "Topping"),
+	}}
+	want := []IngredientChange{
+		{Change: ChangeChanged, IngredientID: flour.Hex(), From: &from.IngredientLines[0], To: &to.IngredientLines[0]},
+		{Change: ChangeRemoved, IngredientID: salt.Hex(), From: &from.IngredientLines[1]},
+		{Change: ChangeAdded, IngredientID: sugar.Hex(), Group: "Topping", To: &to.IngredientLines[3]},
+	}
+	if got := DiffRecipes(from, to).Ingredients; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ingredients = %+v, want %+v", got, want)
+	}
+}
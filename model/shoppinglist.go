@@ -0,0 +1,91 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ShoppingListRecipe is one recipe to shop for. Multiplier scales its
+// quantities, so 2 buys for twice the servings the recipe makes. It
+// defaults to 1.
+type ShoppingListRecipe struct {
+	RecipeID   string  `json:"recipe_id"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+type ShoppingListRequest struct {
+	Recipes []ShoppingListRecipe `json:"recipes"`
+}
+
+// Validate checks the request and fills in default multipliers.
+func (r *ShoppingListRequest) Validate() error {
+	if len(r.Recipes) == 0 {
+		return errors.New("recipes is required")
+	}
+	for i := range r.Recipes {
+		if r.Recipes[i].Multiplier < 0 {
+			return errors.New("multiplier must not be negative")
+		}
+		if r.Recipes[i].Multiplier == 0 {
+			r.Recipes[i].Multiplier = 1
+		}
+	}
+	return nil
+}
+
+type ShoppingListItem struct {
+	ID         int        `json:"id"`
+	Ingredient Ingredient `json:"ingredient"`
+	Quantity   float64    `json:"quantity"`
+	Unit       string     `json:"unit"`
+	Checked    bool       `json:"checked"`
+}
+
+type ShoppingListAisle struct {
+	Aisle string             `json:"aisle"`
+	Items []ShoppingListItem `json:"items"`
+}
+
+type ShoppingList struct {
+	ID        string    `json:"_id" bson:"_id"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// MealPlanID is set on lists made from a meal plan.
+	MealPlanID string               `json:"meal_plan_id,omitempty"`
+	Recipes    []ShoppingListRecipe `json:"recipes"`
+	Aisles     []ShoppingListAisle  `json:"aisles"`
+	// Unresolved lists the recipe lines whose ingredient was deleted. They
+	// are not on the list, so it is not complete.
+	Unresolved []ShoppingListGap `json:"unresolved,omitempty"`
+	// DeletedRecipes are the IDs of meal plan recipes that were deleted.
+	// Their ingredients are not on the list.
+	DeletedRecipes []string `json:"deleted_recipes,omitempty"`
+}
+
+// ShoppingListGap is a recipe line left off a shopping list because its
+// ingredient no longer exists. Quantity is scaled like the list's items.
+type ShoppingListGap struct {
+	RecipeID     string  `json:"recipe_id"`
+	RecipeName   string  `json:"recipe_name"`
+	IngredientID string  `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+// SetChecked checks or unchecks an item and reports whether the list has an
+// item with that ID.
+func (l *ShoppingList) SetChecked(itemID int, checked bool) bool {
+	for i := range l.Aisles {
+		for j := range l.Aisles[i].Items {
+			if l.Aisles[i].Items[j].ID == itemID {
+				l.Aisles[i].Items[j].Checked = checked
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type ShoppingListItemUpdate struct {
+	Checked bool `json:"checked"`
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"rest/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Substitution is a way to replace an ingredient, for example butter with
+// margarine, or buttermilk with milk and lemon juice. Together the
+// substitutions form a graph between ingredients: a replacement can itself
+// have substitutions.
+type Substitution struct {
+	ID         string             `json:"_id" bson:"_id"`
+	Ingredient primitive.ObjectID `json:"ingredient"`
+	// Replacements are the ingredients used instead, together.
+	Replacements []Replacement `json:"replacements"`
+	// Note says when the substitution works, like "baking only".
+	Note    string `json:"note,omitempty"`
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+// Replacement is one of the ingredients a substitution uses. Its quantity is
+// the quantity of the replaced ingredient times Ratio, in the same unit
+// unless Unit is set: 1 egg is replaced by 3 tbsp of aquafaba with a ratio
+// of 3 and a unit of tbsp.
+type Replacement struct {
+	Ingredient primitive.ObjectID `json:"ingredient"`
+	Ratio      float64            `json:"ratio"`
+	Unit       string             `json:"unit,omitempty"`
+}
+
+type SubstitutionWithoutID struct {
+	Ingredient   primitive.ObjectID `json:"ingredient"`
+	Replacements []Replacement      `json:"replacements"`
+	Note         string             `json:"note,omitempty"`
+	OwnerID      string             `json:"owner_id,omitempty"`
+}
+
+// Validate checks that a substitution can be stored and rewrites the units
+// of its replacements to their canonical symbols.
+func (s *SubstitutionWithoutID) Validate() error {
+	if s.Ingredient.IsZero() {
+		return errors.New("ingredient is required")
+	}
+	if len(s.Replacements) == 0 {
+		return errors.New("replacements are required")
+	}
+	for i := range s.Replacements {
+		replacement := &s.Replacements[i]
+		if replacement.Ingredient.IsZero() {
+			return fmt.Errorf("replacement %d: ingredient is required", i+1)
+		}
+		if replacement.Ingredient == s.Ingredient {
+			return fmt.Errorf("replacement %d: an ingredient cannot replace itself", i+1)
+		}
+		if replacement.Ratio <= 0 {
+			return fmt.Errorf("replacement %d: ratio must be positive", i+1)
+		}
+		if replacement.Unit != "" {
+			unit, err := units.Normalize(replacement.Unit)
+			if err != nil {
+				return fmt.Errorf("replacement %d: %w", i+1, err)
+			}
+			replacement.Unit = unit
+		}
+	}
+	return nil
+}
+
+// WithID returns the substitution stored under ID.
+func (s *SubstitutionWithoutID) WithID(ID string) *Substitution {
+	return &Substitution{ID: ID, Ingredient: s.Ingredient, Replacements: s.Replacements, Note: s.Note, OwnerID: s.OwnerID}
+}
+
+// UsesIngredient reports whether the substitution replaces the ingredient or
+// replaces another one with it.
+func (s *Substitution) UsesIngredient(ID primitive.ObjectID) bool {
+	if s.Ingredient == ID {
+		return true
+	}
+	for _, replacement := range s.Replacements {
+		if replacement.Ingredient == ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the lines that replace line, with their quantities scaled
+// and simplified like scaled recipes. Note, group and whether the line is
+// optional carry over.
+func (s *Substitution) Apply(line IngredientLine) []IngredientLine {
+	lines := make([]IngredientLine, len(s.Replacements))
+	for i, replacement := range s.Replacements {
+		unit := line.Unit
+		if replacement.Unit != "" {
+			unit = replacement.Unit
+		}
+		quantity := units.Scale(units.Quantity{Amount: line.Quantity, Unit: unit}, replacement.Ratio)
+		lines[i] = IngredientLine{
+			Ingredient:     replacement.Ingredient,
+			IngredientMeta: IngredientMeta{Quantity: quantity.Amount, Unit: quantity.Unit},
+			Note:           line.Note,
+			Optional:       line.Optional,
+			Group:          line.Group,
+		}
+	}
+	return lines
+}
+
+// SubstitutionReason is why an ingredient of a recipe has to be replaced.
+type SubstitutionReason string
+
+const (
+	ReasonMissing  SubstitutionReason = "missing"
+	ReasonAllergen SubstitutionReason = "allergen"
+	ReasonDiet     SubstitutionReason = "diet"
+)
+
+// AppliedSubstitution is an ingredient line of a recipe and the lines that
+// replace it. Substitutions can be chained, like butter to margarine to
+// vegetable oil, in which case Notes has the note of every step.
+type AppliedSubstitution struct {
+	Replaced ResolvedIngredientLine   `json:"replaced"`
+	With     []ResolvedIngredientLine `json:"with"`
+	Reason   SubstitutionReason       `json:"reason"`
+	Notes    []string                 `json:"notes,omitempty"`
+}
+
+// UnreplacedIngredient is an ingredient line that had to be replaced but
+// has no usable substitution. Optional lines are left out of the variant;
+// other lines are kept.
+type UnreplacedIngredient struct {
+	Line    ResolvedIngredientLine `json:"line"`
+	Reason  SubstitutionReason     `json:"reason"`
+	Omitted bool                   `json:"omitted"`
+}
+
+// RecipeVariant is a recipe with ingredients replaced by substitutes.
+type RecipeVariant struct {
+	Recipe        *ResolvedRecipe        `json:"recipe"`
+	Substitutions []AppliedSubstitution  `json:"substitutions"`
+	Unreplaced    []UnreplacedIngredient `json:"unreplaced"`
+	// Complete is true when every ingredient that had to go was replaced
+	// or left out.
+	Complete bool `json:"complete"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Role decides what a user may do; see the policy in the rest package.
+type Role string
+
+const (
+	// Viewers can only read.
+	RoleViewer Role = "viewer"
+	// Editors can also add recipes and ingredients, and change their own.
+	RoleEditor Role = "editor"
+	// Admins can change everything and manage users.
+	RoleAdmin Role = "admin"
+)
+
+var AllRoles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+func (r Role) IsValid() bool {
+	for _, role := range AllRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// User is an account as it is stored. Handlers respond with UserInfo, which
+// leaves out the password hash.
+type User struct {
+	ID           string    `json:"_id" bson:"_id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// Info returns the fields of the user that are safe to show.
+func (u *User) Info() UserInfo {
+	return UserInfo{ID: u.ID, Username: u.Username, Role: u.Role, CreatedAt: u.CreatedAt}
+}
+
+type UserInfo struct {
+	ID        string    `json:"_id"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (c *Credentials) Validate() error {
+	if c.Username == "" {
+		return errors.New("username is required")
+	}
+	if c.Password == "" {
+		return errors.New("password is required")
+	}
+	return nil
+}
+
+type RoleUpdate struct {
+	Role Role `json:"role"`
+}
+
+func (u *RoleUpdate) Validate() error {
+	if !u.Role.IsValid() {
+		return fmt.Errorf("invalid role %q", u.Role)
+	}
+	return nil
+}
+
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      UserInfo  `json:"user"`
+}
@@ -0,0 +1,231 @@
+// Package nutrition reads nutrition facts from tables like the USDA food
+// composition CSV exports and matches their foods to ingredients.
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rest/model"
+	"rest/search"
+)
+
+// Record is a row of a nutrition table: a food and its nutrition per 100 g.
+type Record struct {
+	Name      string
+	Nutrition model.Nutrition
+}
+
+type column int
+
+const (
+	columnName column = iota + 1
+	columnEnergy
+	columnProtein
+	columnFat
+	columnCarbohydrates
+	columnFiber
+	columnSugar
+	columnSalt
+	columnSodium
+)
+
+// headers maps the column names used by USDA exports and similar tables,
+// without their unit, to what the column holds.
+var headers = map[string]column{
+	"name":                         columnName,
+	"description":                  columnName,
+	"food":                         columnName,
+	"food description":             columnName,
+	"shrt desc":                    columnName,
+	"long desc":                    columnName,
+	"energy":                       columnEnergy,
+	"energ kcal":                   columnEnergy,
+	"calories":                     columnEnergy,
+	"kcal":                         columnEnergy,
+	"protein":                      columnProtein,
+	"fat":                          columnFat,
+	"total fat":                    columnFat,
+	"total lipid":                  columnFat,
+	"total lipid (fat)":            columnFat,
+	"lipid tot":                    columnFat,
+	"carbohydrate":                 columnCarbohydrates,
+	"carbohydrates":                columnCarbohydrates,
+	"carbohydrate, by difference":  columnCarbohydrates,
+	"carbohydrt":                   columnCarbohydrates,
+	"fiber":                        columnFiber,
+	"fibre":                        columnFiber,
+	"fiber, total dietary":         columnFiber,
+	"fiber td":                     columnFiber,
+	"sugar":                        columnSugar,
+	"sugars":                       columnSugar,
+	"sugars, total":                columnSugar,
+	"sugars, total including nlea": columnSugar,
+	"sugar tot":                    columnSugar,
+	"salt":                         columnSalt,
+	"sodium":                       columnSodium,
+	"sodium, na":                   columnSodium,
+}
+
+// headerUnit is the unit at the end of a column name, as in "Protein (g)".
+var headerUnit = regexp.MustCompile(`\s*\(([a-zA-Z]+)\)$`)
+
+var ErrNoNameColumn = errors.New("the table has no name or description column")
+
+// ReadCSV reads a nutrition table with a header row. Values are per 100 g.
+// Energy is in kcal unless the column says kJ, sodium in mg unless the
+// column says g; sodium is turned into salt when there is no salt column.
+// Unknown columns are ignored and empty cells count as zero.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make([]column, len(header))
+	scales := make([]float64, len(header))
+	hasName, hasSalt := false, false
+	for i, name := range header {
+		columns[i], scales[i] = parseHeader(name)
+		hasName = hasName || columns[i] == columnName
+		hasSalt = hasSalt || columns[i] == columnSalt
+	}
+	if !hasName {
+		return nil, ErrNoNameColumn
+	}
+
+	var records []Record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record Record
+		for i, cell := range row {
+			if i >= len(columns) || columns[i] == 0 {
+				continue
+			}
+			cell = strings.TrimSpace(cell)
+			if columns[i] == columnName {
+				record.Name = cell
+				continue
+			}
+			if cell == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, column %q: %w", line, header[i], err)
+			}
+			value *= scales[i]
+			switch columns[i] {
+			case columnEnergy:
+				record.Nutrition.Energy = value
+			case columnProtein:
+				record.Nutrition.Protein = value
+			case columnFat:
+				record.Nutrition.Fat = value
+			case columnCarbohydrates:
+				record.Nutrition.Carbohydrates = value
+			case columnFiber:
+				record.Nutrition.Fiber = value
+			case columnSugar:
+				record.Nutrition.Sugar = value
+			case columnSalt:
+				record.Nutrition.Salt = value
+			case columnSodium:
+				if !hasSalt {
+					// Salt is 40% sodium by weight.
+					record.Nutrition.Salt = value * 2.5
+				}
+			}
+		}
+		if record.Name != "" {
+			records = append(records, record)
+		}
+	}
+}
+
+// parseHeader finds what a column holds and the factor that converts its
+// values to kcal or grams.
+func parseHeader(name string) (column, float64) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	unit := ""
+	if match := headerUnit.FindStringSubmatch(name); match != nil {
+		unit = match[1]
+		name = name[:len(name)-len(match[0])]
+	}
+	name = strings.TrimSpace(strings.ReplaceAll(name, "_", " "))
+	kind, ok := headers[name]
+	if !ok {
+		return 0, 0
+	}
+	switch {
+	case kind == columnEnergy && unit == "kj":
+		return kind, 1 / 4.184
+	case kind == columnSodium && unit != "g":
+		return kind, 0.001
+	case unit == "mg":
+		return kind, 0.001
+	}
+	return kind, 1
+}
+
+// Match finds the record for an ingredient. It prefers a record with the
+// same name, then one whose description starts with the name, as in
+// "Tomatoes, red, ripe, raw" for tomatoes, then the one with the fewest
+// other words that has all of the name's words. Case and plurals are
+// ignored.
+func Match(records []Record, name string) (Record, bool) {
+	for _, record := range records {
+		if strings.EqualFold(record.Name, name) {
+			return record, true
+		}
+	}
+	terms := search.Terms(name)
+	if len(terms) == 0 {
+		return Record{}, false
+	}
+	for _, record := range records {
+		first, _, _ := strings.Cut(record.Name, ",")
+		if sameTerms(search.Terms(first), terms) {
+			return record, true
+		}
+	}
+	best, bestExtra := Record{}, -1
+	for _, record := range records {
+		recordTerms := search.Terms(record.Name)
+		if !containsTerms(recordTerms, terms) {
+			continue
+		}
+		if extra := len(recordTerms) - len(terms); bestExtra < 0 || extra < bestExtra {
+			best, bestExtra = record, extra
+		}
+	}
+	return best, bestExtra >= 0
+}
+
+func sameTerms(a, b []string) bool {
+	return len(a) == len(b) && containsTerms(a, b)
+}
+
+// containsTerms reports whether every term of b is in a.
+func containsTerms(a, b []string) bool {
+	for _, term := range b {
+		if !slices.Contains(a, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,336 @@
+// Package recipetext reads ingredient lines written as free text, like
+// "2 1/2 cups all-purpose flour, sifted", into their quantity, unit,
+// ingredient name and preparation note.
+package recipetext
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rest/units"
+)
+
+var (
+	ErrEmpty = errors.New("line is empty")
+	// ErrNoName is returned for lines that only have a quantity and unit.
+	ErrNoName = errors.New("line names no ingredient")
+	// ErrBadQuantity is returned for quantities that are not numbers, like
+	// "1/0" or "1/".
+	ErrBadQuantity = errors.New("quantity is not a number")
+)
+
+// Line is an ingredient line read from text. Quantity is zero and Unit
+// empty when the text gives no amount, as in "salt to taste".
+type Line struct {
+	Quantity float64
+	// Unit is the symbol of a known unit, see units.Normalize, or empty for
+	// a plain count.
+	Unit     string
+	Name     string
+	Note     string
+	Optional bool
+}
+
+// number is a quantity as written in recipes: "2", "1.5", "1,5", "1,000",
+// "1/2" or "2 1/2".
+const number = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:,\d+)*(?:\.\d+)?`
+
+var (
+	// quantityPattern matches a number or a range of numbers, like "2-3"
+	// or "2 to 3", at the start of a line.
+	quantityPattern = regexp.MustCompile(`^(` + number + `)(?:\s*(?:-|–|to\s)\s*(` + number + `))?`)
+	// sizePattern matches a package size like "400g" or "14 oz".
+	sizePattern = regexp.MustCompile(`^(` + number + `)\s*([a-zA-Z. ]+)$`)
+	// danglingFraction matches the rest of a fraction that has no
+	// denominator, like "/" in "1/ cup" or " 1/" in "2 1/ cups".
+	danglingFraction = regexp.MustCompile(`^\s*\d*/`)
+	parenthesis      = regexp.MustCompile(`\(([^)]*)\)`)
+	whitespace       = regexp.MustCompile(`\s+`)
+)
+
+var vulgarFractions = map[rune]string{
+	'¼': "1/4", '½': "1/2", '¾': "3/4",
+	'⅓': "1/3", '⅔': "2/3",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// preparations are words that say how an ingredient is prepared. Leading
+// ones are moved from the name to the note, so "chopped tomatoes" is
+// tomatoes, chopped.
+var preparations = map[string]bool{
+	"beaten": true, "chopped": true, "crumbled": true, "crushed": true,
+	"cubed": true, "diced": true, "drained": true, "grated": true,
+	"halved": true, "julienned": true, "mashed": true, "melted": true,
+	"minced": true, "peeled": true, "quartered": true, "rinsed": true,
+	"shredded": true, "sifted": true, "sliced": true, "softened": true,
+	"toasted": true, "trimmed": true,
+}
+
+// manners are adverbs that go with a preparation, as in "finely chopped".
+var manners = map[string]bool{
+	"coarsely": true, "finely": true, "freshly": true, "lightly": true,
+	"roughly": true, "thinly": true, "thickly": true,
+}
+
+// Heading returns the group heading a line such as "For the sauce:" stands
+// for. ok is false for lines that are not headings.
+func Heading(text string) (heading string, ok bool) {
+	text = clean(text)
+	heading, ok = strings.CutSuffix(text, ":")
+	if !ok || heading == "" || quantityPattern.MatchString(heading) {
+		return "", false
+	}
+	return strings.TrimSpace(heading), true
+}
+
+// Parse reads an ingredient line. A parenthesized package size, as in
+// "1 (400g) can chopped tomatoes", becomes the quantity when it is a mass
+// or volume, with the count kept in the note.
+func Parse(text string) (Line, error) {
+	text = clean(text)
+	if text == "" {
+		return Line{}, ErrEmpty
+	}
+	var line Line
+	var notes []string
+	var size *units.Quantity
+	text = parenthesis.ReplaceAllStringFunc(text, func(match string) string {
+		inner := strings.TrimSpace(match[1 : len(match)-1])
+		if quantity, ok := parseSize(inner); ok && size == nil {
+			size = &quantity
+		} else {
+			for _, note := range strings.Split(inner, ",") {
+				notes = append(notes, strings.TrimSpace(note))
+			}
+		}
+		return " "
+	})
+	text = clean(text)
+	if main, note, ok := cutNote(text); ok {
+		text = main
+		notes = append([]string{note}, notes...)
+	}
+
+	rest := text
+	quantity, hasQuantity := 0.0, false
+	if match := quantityPattern.FindStringSubmatch(rest); match != nil {
+		var err error
+		if quantity, err = parseNumber(match[1]); err != nil {
+			return Line{}, err
+		}
+		if match[2] != "" {
+			upper, err := parseNumber(match[2])
+			if err != nil {
+				return Line{}, err
+			}
+			// Ranges are read as the amount in the middle.
+			quantity = (quantity + upper) / 2
+		}
+		hasQuantity = true
+		if dangling := danglingFraction.FindString(rest[len(match[0]):]); dangling != "" {
+			return Line{}, fmt.Errorf("%w: %q", ErrBadQuantity, match[0]+dangling)
+		}
+		rest = strings.TrimSpace(rest[len(match[0]):])
+	} else if word, after, ok := strings.Cut(rest, " "); ok && (strings.EqualFold(word, "a") || strings.EqualFold(word, "an")) {
+		quantity, hasQuantity = 1, true
+		rest = after
+	}
+	unit, afterUnit, hasUnit := cutUnit(rest)
+	if hasUnit {
+		rest = afterUnit
+		if !hasQuantity {
+			quantity, hasQuantity = 1, true
+		}
+	}
+	counted := strings.TrimSpace(strings.TrimSuffix(text, rest))
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "of "))
+
+	name, preparation := cutPreparation(rest)
+	if preparation != "" {
+		notes = append([]string{preparation}, notes...)
+	}
+	if before, ok := strings.CutSuffix(name, " to taste"); ok {
+		name = before
+		notes = append(notes, "to taste")
+	}
+	line.Name = strings.TrimSpace(name)
+	if line.Name == "" {
+		return Line{}, ErrNoName
+	}
+
+	line.Quantity, line.Unit = quantity, unit
+	if size != nil {
+		unitInfo, _ := units.Parse(unit)
+		if !hasUnit || unitInfo.Dimension == units.Count {
+			if !hasQuantity {
+				quantity = 1
+			}
+			line.Quantity, line.Unit = quantity*size.Amount, size.Unit
+			if counted != "" {
+				notes = append(notes, counted)
+			}
+		} else {
+			notes = append(notes, strconv.FormatFloat(size.Amount, 'f', -1, 64)+" "+size.Unit)
+		}
+	}
+
+	kept := notes[:0]
+	for _, note := range notes {
+		if strings.EqualFold(note, "optional") {
+			line.Optional = true
+			continue
+		}
+		if note != "" {
+			kept = append(kept, note)
+		}
+	}
+	line.Note = strings.Join(kept, ", ")
+	return line, nil
+}
+
+// clean drops list bullets and extra whitespace, and spells out vulgar
+// fractions, so "2½" reads as "2 1/2".
+func clean(text string) string {
+	var cleaned strings.Builder
+	for _, r := range text {
+		if fraction, ok := vulgarFractions[r]; ok {
+			cleaned.WriteString(" " + fraction)
+			continue
+		}
+		cleaned.WriteRune(r)
+	}
+	text = whitespace.ReplaceAllString(strings.TrimSpace(cleaned.String()), " ")
+	return strings.TrimSpace(strings.TrimLeft(text, "-*•· "))
+}
+
+// cutNote splits a line at the first comma that is not part of a number, as
+// in "1,5 kg" or "1,000 g".
+func cutNote(text string) (main string, note string, ok bool) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != ',' {
+			continue
+		}
+		if i > 0 && i+1 < len(text) && isDigit(text[i-1]) && isDigit(text[i+1]) {
+			continue
+		}
+		return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+	}
+	return text, "", false
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// parseNumber reads a number matched by the number pattern. It returns
+// ErrBadQuantity for fractions with a zero denominator.
+func parseNumber(text string) (float64, error) {
+	total := 0.0
+	for _, field := range strings.Fields(text) {
+		if numerator, denominator, ok := strings.Cut(field, "/"); ok {
+			n, err := strconv.ParseFloat(numerator, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%w: %q", ErrBadQuantity, text)
+			}
+			d, err := strconv.ParseFloat(denominator, 64)
+			if err != nil || d == 0 {
+				return 0, fmt.Errorf("%w: %q", ErrBadQuantity, text)
+			}
+			total += n / d
+			continue
+		}
+		plain, ok := plainNumber(field)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrBadQuantity, text)
+		}
+		value, err := strconv.ParseFloat(plain, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrBadQuantity, text)
+		}
+		total += value
+	}
+	return total, nil
+}
+
+// plainNumber rewrites a number with commas for strconv. Commas followed by
+// exactly three digits separate thousands, as in "1,000" or "1,250.5";
+// otherwise a single comma is a decimal comma, as in "1,5". Numbers that are
+// neither, like "1,5,2", are not valid.
+func plainNumber(text string) (string, bool) {
+	integer, fraction, hasFraction := strings.Cut(text, ".")
+	groups := strings.Split(integer, ",")
+	if len(groups) == 1 {
+		return text, true
+	}
+	thousands := len(groups[0]) <= 3
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			thousands = false
+		}
+	}
+	switch {
+	case thousands && hasFraction:
+		return strings.Join(groups, "") + "." + fraction, true
+	case thousands:
+		return strings.Join(groups, ""), true
+	case len(groups) == 2 && !hasFraction:
+		return groups[0] + "." + groups[1], true
+	}
+	return "", false
+}
+
+// parseSize reads a package size, which must be a mass or volume.
+func parseSize(text string) (units.Quantity, bool) {
+	match := sizePattern.FindStringSubmatch(text)
+	if match == nil {
+		return units.Quantity{}, false
+	}
+	unit, ok := units.Parse(match[2])
+	if !ok || unit.Dimension == units.Count {
+		return units.Quantity{}, false
+	}
+	amount, err := parseNumber(match[1])
+	if err != nil {
+		return units.Quantity{}, false
+	}
+	return units.Quantity{Amount: amount, Unit: unit.Symbol}, true
+}
+
+// cutUnit takes a unit off the start of text, trying two-word units like
+// "fl oz" first. A unit written right after the number, as in "400g", is
+// found as well.
+func cutUnit(text string) (symbol string, rest string, ok bool) {
+	words := strings.Fields(text)
+	for n := min(2, len(words)); n >= 1; n-- {
+		candidate := strings.Join(words[:n], " ")
+		if unit, found := units.Parse(candidate); found && unit.Symbol != "" {
+			return unit.Symbol, strings.Join(words[n:], " "), true
+		}
+	}
+	return "", text, false
+}
+
+// cutPreparation moves leading preparation words, like "finely chopped",
+// out of an ingredient name.
+func cutPreparation(name string) (string, string) {
+	words := strings.Fields(name)
+	end := 0
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		if preparations[lower] {
+			end = i + 1
+			continue
+		}
+		if !manners[lower] {
+			break
+		}
+	}
+	// Leave at least one word for the name.
+	if end == 0 || end == len(words) {
+		return name, ""
+	}
+	return strings.Join(words[end:], " "), strings.Join(words[:end], " ")
+}
@@ -0,0 +1,79 @@
+package recipetext
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Line
+	}{
+		{"2 1/2 cups all-purpose flour, sifted", Line{Quantity: 2.5, Unit: "cup", Name: "all-purpose flour", Note: "sifted"}},
+		{"- 3 eggs", Line{Quantity: 3, Name: "eggs"}},
+		{"1,5 kg potatoes, peeled", Line{Quantity: 1.5, Unit: "kg", Name: "potatoes", Note: "peeled"}},
+		{"1,000 g flour", Line{Quantity: 1000, Unit: "g", Name: "flour"}},
+		{"1,250.5 g sugar, sifted", Line{Quantity: 1250.5, Unit: "g", Name: "sugar", Note: "sifted"}},
+		{"2,25 l water", Line{Quantity: 2.25, Unit: "l", Name: "water"}},
+		{"1,000-2,000 g potatoes", Line{Quantity: 1500, Unit: "g", Name: "potatoes"}},
+		{"2-3 cloves garlic", Line{Quantity: 2.5, Unit: "clove", Name: "garlic"}},
+		{"½ tsp salt (optional)", Line{Quantity: 0.5, Unit: "tsp", Name: "salt", Optional: true}},
+		{"a pinch of salt", Line{Quantity: 1, Unit: "pinch", Name: "salt"}},
+		{"salt to taste", Line{Name: "salt", Note: "to taste"}},
+		{"1 (400g) can chopped tomatoes", Line{Quantity: 400, Unit: "g", Name: "tomatoes", Note: "chopped, 1 can"}},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.text)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %v", test.text, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want error
+	}{
+		{"", ErrEmpty},
+		{"  * ", ErrEmpty},
+		{"2 cups", ErrNoName},
+		{"1/0 cup flour", ErrBadQuantity},
+		{"0/0 tsp salt", ErrBadQuantity},
+		{"1 1/0 cups sugar", ErrBadQuantity},
+		{"1-2/0 cups milk", ErrBadQuantity},
+		{"1/ cup flour", ErrBadQuantity},
+		{"2 1/ cups flour", ErrBadQuantity},
+		{"1,5,2 kg flour", ErrBadQuantity},
+		{"1,5.2 kg flour", ErrBadQuantity},
+	}
+	for _, test := range tests {
+		if got, err := Parse(test.text); !errors.Is(err, test.want) {
+			t.Errorf("Parse(%q) = %+v, %v, want error %v", test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestHeading(t *testing.T) {
+	tests := []struct {
+		text    string
+		heading string
+		ok      bool
+	}{
+		{"For the sauce:", "For the sauce", true},
+		{"2 cups:", "", false},
+		{"2 cups flour", "", false},
+		{":", "", false},
+	}
+	for _, test := range tests {
+		heading, ok := Heading(test.text)
+		if heading != test.heading || ok != test.ok {
+			t.Errorf("Heading(%q) = %q, %v, want %q, %v", test.text, heading, ok, test.heading, test.ok)
+		}
+	}
+}
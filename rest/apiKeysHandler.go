@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"rest/auth"
+	"rest/database"
+	"rest/model"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AllAPIKeys godoc
+// @Summary Get API keys
+// @Description get the caller's API keys, or every key for admins
+// @ID allapikeys
+// @Produce json
+// @Success 200 {object} []model.APIKeyInfo
+// @Failure 401 {object} ErrorResponse
+// @Router /api-keys [get]
+func (a *App) getAllAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	keys := a.Database.AllAPIKeys()
+	if keys == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load API keys"))
+		return
+	}
+	user := currentUser(r)
+	infos := []model.APIKeyInfo{}
+	for _, key := range keys {
+		if canModify(user, key.OwnerID) {
+			infos = append(infos, key.APIKeyInfo)
+		}
+	}
+	data, err := loadDataAsJSON(infos)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load API keys"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetAPIKey godoc
+// @Summary Get API key by ID
+// @Description get an API key, including how often it has been used
+// @ID getapikey
+// @Produce json
+// @Param        id   path      string  true  "API key ID"
+// @Success 200 {object} model.APIKeyInfo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/{id} [get]
+func (a *App) getAPIKeyByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	key := a.Database.FindAPIKeyByID(chi.URLParam(r, "id"))
+	// Other users' keys are reported as missing rather than forbidden, so
+	// their IDs cannot be probed.
+	if key == nil || !canModify(currentUser(r), key.OwnerID) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find API key"))
+		return
+	}
+	data, err := loadDataAsJSON(key.APIKeyInfo)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load API key"))
+		return
+	}
+	w.Write(data)
+}
+
+// AddAPIKey godoc
+// @Summary Create an API key
+// @Description create an API key for the caller. The key is only included in this response; send it as the X-API-Key header. A key can only have scopes the caller's role grants.
+// @ID addapikey
+// @Produce json
+// Accept json
+// @Param  key   body  model.APIKeyRequest  true  "API key"
+// @Success 201 {object} model.CreatedAPIKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api-keys [post]
+func (a *App) AddAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.APIKeyRequest
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode API key"))
+		return
+	}
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	user := currentUser(r)
+	for _, scope := range body.Scopes {
+		if !roleAllows(user.Role, scope) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(getErrorResponse(fmt.Sprintf("The %s role cannot grant the %s scope", user.Role, scope)))
+			return
+		}
+	}
+	raw, err := auth.NewAPIKey()
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to create API key"))
+		return
+	}
+	key := &model.APIKey{
+		APIKeyInfo: model.APIKeyInfo{
+			Prefix:    raw[:auth.APIKeyPrefixLength],
+			Name:      body.Name,
+			Scopes:    body.Scopes,
+			OwnerID:   user.ID,
+			RateLimit: body.RateLimit,
+			CreatedAt: time.Now().UTC(),
+		},
+		KeyHash: auth.HashAPIKey(raw),
+	}
+	if err := a.Database.SaveAPIKey(key); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to create API key"))
+		return
+	}
+	data, err := loadDataAsJSON(model.CreatedAPIKey{APIKeyInfo: key.APIKeyInfo, Key: raw})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load API key"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description revoke an API key. Requests made with it are rejected from then on.
+// @ID revokeapikey
+// @Produce json
+// @Param        id   path      string  true  "API key ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/{id} [delete]
+func (a *App) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	key := a.Database.FindAPIKeyByID(chi.URLParam(r, "id"))
+	if key == nil || !canModify(currentUser(r), key.OwnerID) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find API key"))
+		return
+	}
+	err := a.Database.RevokeAPIKey(key.ID, time.Now().UTC())
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find API key"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to revoke API key"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,17 +1,49 @@
 package rest
 
 import (
+	"crypto/rand"
+	"log"
 	"net/http"
 	"rest/database"
+	"time"
 )
 
+const trashPurgeInterval = time.Hour
+
 type App struct {
 	Router   http.Handler
-	Database database.DB
+	Database database.Store
+	Config   Config
+	limiter  *rateLimiter
 }
 
-func New() App {
-	app := App{Database: *database.Connect()}
+func New(store database.Store, config Config) App {
+	if len(config.JWTKey) == 0 {
+		log.Print("JWT_KEY is not set, using a random key: logins will not survive a restart")
+		config.JWTKey = make([]byte, 32)
+		if _, err := rand.Read(config.JWTKey); err != nil {
+			panic(err)
+		}
+	}
+	app := App{Database: store, Config: config, limiter: newRateLimiter()}
 	app.loadRoutes()
+	go app.purgeTrashPeriodically()
 	return app
 }
+
+// purgeTrashPeriodically removes recipes that have been in the trash for
+// longer than the configured retention period.
+func (a *App) purgeTrashPeriodically() {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		purged, err := a.Database.PurgeTrash(time.Now().Add(-a.Config.TrashRetention))
+		if err != nil {
+			log.Print("failed to purge trash: ", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("purged %d recipes from the trash", purged)
+		}
+	}
+}
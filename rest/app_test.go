@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"rest/database"
+	"rest/model"
+	"testing"
+	"time"
+)
+
+// newTestApp returns an app backed by an empty in-memory store.
+func newTestApp() *App {
+	app := New(database.NewMemoryStore(), Config{TokenTTL: time.Hour})
+	return &app
+}
+
+// signUp registers a user and logs them in. The first user is an admin,
+// later ones are viewers.
+func signUp(t *testing.T, app *App, username string) (token string, ID string) {
+	t.Helper()
+	credentials := model.Credentials{Username: username, Password: "secret123"}
+	var user model.UserInfo
+	if w := request(t, app, "POST", "/users", "", "", credentials, &user); w.Code != http.StatusCreated {
+		t.Fatalf("registering %s: %d %s", username, w.Code, w.Body)
+	}
+	var login model.TokenResponse
+	if w := request(t, app, "POST", "/auth/login", "", "", credentials, &login); w.Code != http.StatusOK {
+		t.Fatalf("logging in %s: %d %s", username, w.Code, w.Body)
+	}
+	return login.Token, user.ID
+}
+
+// request sends a JSON request to the app and decodes the response into
+// out, if it is not nil.
+func request(t *testing.T, app *App, method, path, token, etag string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, path, &reader)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if etag != "" {
+		r.Header.Set("If-Match", etag)
+	}
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, r)
+	if out != nil && w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decoding %s: %v", method, path, w.Body, err)
+		}
+	}
+	return w
+}
+
+// signUpEditor registers a user and has admin make them an editor.
+func signUpEditor(t *testing.T, app *App, admin string, username string) (token string, ID string) {
+	t.Helper()
+	token, ID = signUp(t, app, username)
+	if w := request(t, app, "PUT", "/users/"+ID+"/role", admin, "", model.RoleUpdate{Role: model.RoleEditor}, nil); w.Code != http.StatusOK {
+		t.Fatalf("making %s an editor: %d %s", username, w.Code, w.Body)
+	}
+	return token, ID
+}
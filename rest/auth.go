@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"rest/auth"
+	"rest/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type contextKey string
+
+const (
+	userContextKey   contextKey = "user"
+	apiKeyContextKey contextKey = "apiKey"
+)
+
+// authenticate identifies the caller from a bearer token or an X-API-Key
+// header. Requests without either go through anonymously; requests with bad
+// credentials are rejected rather than treated as anonymous, so clients
+// notice expired logins and revoked keys.
+func (a *App) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			a.authenticateAPIKey(next, w, r, key)
+			return
+		}
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			unauthorized(w, "Authorization must be a bearer token")
+			return
+		}
+		claims, err := auth.ParseToken(token, a.Config.JWTKey)
+		if err != nil {
+			unauthorized(w, "Invalid or expired token")
+			return
+		}
+		user := a.Database.FindUserByID(claims.Subject)
+		if user == nil {
+			unauthorized(w, "Invalid or expired token")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	})
+}
+
+// authenticateAPIKey lets a request made with an API key act as the key's
+// owner, as long as the key is within its rate limit.
+func (a *App) authenticateAPIKey(next http.Handler, w http.ResponseWriter, r *http.Request, raw string) {
+	key := a.Database.FindAPIKeyByHash(auth.HashAPIKey(raw))
+	if key == nil || key.IsRevoked() {
+		unauthorized(w, "Invalid or revoked API key")
+		return
+	}
+	now := time.Now()
+	allowed, wait := a.limiter.allow(key.ID, key.RateLimit, now)
+	if !allowed {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write(getErrorResponse("Rate limit exceeded"))
+		return
+	}
+	if err := a.Database.RecordAPIKeyUse(key.ID, now.UTC()); err != nil {
+		log.Print("failed to record API key use: ", err)
+	}
+	user := a.Database.FindUserByID(key.OwnerID)
+	if user == nil {
+		unauthorized(w, "Invalid or revoked API key")
+		return
+	}
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	ctx = context.WithValue(ctx, apiKeyContextKey, key)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// rejectAPIKeys keeps API keys away from endpoints that only people should
+// use, like creating more keys.
+func rejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if currentAPIKey(r) != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(getErrorResponse("API keys cannot be used for this endpoint"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireUser only lets logged in callers through.
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if currentUser(r) == nil {
+			unauthorized(w, "Not logged in")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write(getErrorResponse(message))
+}
+
+// currentUser returns the logged in caller, or nil for anonymous callers.
+func currentUser(r *http.Request) *model.User {
+	user, _ := r.Context().Value(userContextKey).(*model.User)
+	return user
+}
+
+// currentAPIKey returns the API key the request was made with, if any.
+func currentAPIKey(r *http.Request) *model.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey).(*model.APIKey)
+	return key
+}
+
+// canModify reports whether user may change something owned by ownerID.
+// Things without an owner, like the seed data, can only be changed by
+// admins.
+func canModify(user *model.User, ownerID string) bool {
+	if user == nil {
+		return false
+	}
+	return user.IsAdmin() || ownerID != "" && ownerID == user.ID
+}
@@ -1 +1,64 @@
 package rest
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// ProfileDevelopment is the profile for running the server locally. Only
+// in this profile can the database be seeded with the test data.
+const ProfileDevelopment = "development"
+
+type Config struct {
+	// Profile is "development" or "production".
+	Profile string
+	// Storage selects the storage backend: "mongo", "bolt" or "memory".
+	Storage string
+	// BoltPath is the data file used by the bolt storage backend.
+	BoltPath string
+	// TrashRetention is how long deleted recipes are kept in the trash
+	// before they are purged.
+	TrashRetention time.Duration
+	// JWTKey signs login tokens. When it is empty a random key is used,
+	// so tokens stop working when the server restarts.
+	JWTKey []byte
+	// TokenTTL is how long a login token is valid.
+	TokenTTL time.Duration
+}
+
+func LoadConfig() Config {
+	return Config{
+		Profile:        getEnv("PROFILE", "production"),
+		Storage:        getEnv("STORAGE", "mongo"),
+		BoltPath:       getEnv("BOLT_PATH", "recipes.db"),
+		TrashRetention: getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		JWTKey:         []byte(os.Getenv("JWT_KEY")),
+		TokenTTL:       getEnvDuration("TOKEN_TTL", 24*time.Hour),
+	}
+}
+
+func (c Config) IsDevelopment() bool {
+	return c.Profile == ProfileDevelopment
+}
+
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"rest/model"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CookableRecipes godoc
+// @Summary Find recipes you can cook
+// @Description rank recipes by how many of their ingredients are on hand, and list what is missing for each one
+// @ID cookablerecipes
+// @Produce json
+// Accept json
+// @Param  pantry   body  model.CookableQuery  true  "Ingredient names or IDs on hand"
+// @Success 200 {object} model.CookableResponse
+// @Router /recipes/cookable [post]
+func (a *App) CookableRecipes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.CookableQuery
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode query"))
+		return
+	}
+	if body.MaxMissing != nil && *body.MaxMissing < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("max_missing must not be negative"))
+		return
+	}
+	recipes := a.Database.AllRecipes()
+	ingredients := a.Database.AllIngredients()
+	if recipes == nil || ingredients == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load recipes"))
+		return
+	}
+
+	onHand, unknown := matchIngredients(body.Ingredients, ingredients)
+	response := model.CookableResponse{
+		Recipes:            rankCookableRecipes(recipes, ingredients, onHand, body.MaxMissing),
+		UnknownIngredients: unknown,
+	}
+	data, err := loadDataAsJSON(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load recipes"))
+		return
+	}
+	w.Write(data)
+}
+
+// matchIngredients resolves ingredient IDs, names or aliases to ingredient
+// IDs, matching names like model.FindIngredientByName. Entries that match no
+// ingredient are returned as unknown.
+func matchIngredients(entries []string, ingredients []*model.Ingredient) (map[primitive.ObjectID]bool, []string) {
+	byID := map[string]*model.Ingredient{}
+	for _, ingredient := range ingredients {
+		byID[ingredient.ID] = ingredient
+	}
+
+	matched := map[primitive.ObjectID]bool{}
+	unknown := []string{}
+	for _, entry := range entries {
+		ingredient, ok := byID[entry]
+		if !ok {
+			ingredient = model.FindIngredientByName(ingredients, strings.TrimSpace(entry))
+		}
+		if ingredient == nil {
+			unknown = append(unknown, entry)
+			continue
+		}
+		ingredientID, err := primitive.ObjectIDFromHex(ingredient.ID)
+		if err != nil {
+			unknown = append(unknown, entry)
+			continue
+		}
+		matched[ingredientID] = true
+	}
+	return matched, unknown
+}
+
+// rankCookableRecipes orders recipes by the share of their ingredients that
+// are on hand, then by how few are missing. Recipes missing more than
+// maxMissing ingredients are left out when maxMissing is set.
+func rankCookableRecipes(recipes []*model.Recipe, ingredients []*model.Ingredient, onHand map[primitive.ObjectID]bool, maxMissing *int) []model.CookableRecipe {
+	names := map[string]string{}
+	for _, ingredient := range ingredients {
+		names[ingredient.ID] = ingredient.Name
+	}
+
+	cookable := []model.CookableRecipe{}
+	for _, recipe := range recipes {
+		missing := []model.MissingIngredient{}
+		required := 0
+		for _, line := range recipe.IngredientLines {
+			// Optional ingredients do not keep a recipe from being cooked.
+			if line.Optional {
+				continue
+			}
+			required++
+			if onHand[line.Ingredient] {
+				continue
+			}
+			missing = append(missing, model.MissingIngredient{
+				Ingredient: model.Ingredient{ID: line.Ingredient.Hex(), Name: names[line.Ingredient.Hex()]},
+				Quantity:   line.Quantity,
+				Unit:       line.Unit,
+			})
+		}
+		if maxMissing != nil && len(missing) > *maxMissing {
+			continue
+		}
+		coverage := 1.0
+		if required > 0 {
+			coverage = float64(required-len(missing)) / float64(required)
+		}
+		cookable = append(cookable, model.CookableRecipe{
+			Recipe:   *recipe,
+			Coverage: coverage,
+			Missing:  missing,
+		})
+	}
+
+	sort.SliceStable(cookable, func(i, j int) bool {
+		if cookable[i].Coverage != cookable[j].Coverage {
+			return cookable[i].Coverage > cookable[j].Coverage
+		}
+		if len(cookable[i].Missing) != len(cookable[j].Missing) {
+			return len(cookable[i].Missing) < len(cookable[j].Missing)
+		}
+		return cookable[i].Recipe.Name < cookable[j].Recipe.Name
+	})
+	return cookable
+}
@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"rest/model"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatchIngredients(t *testing.T) {
+	tomatoes := primitive.NewObjectID()
+	onions := primitive.NewObjectID()
+	ingredients := []*model.Ingredient{
+		{ID: tomatoes.Hex(), Name: "tomatoes"},
+		{ID: onions.Hex(), Name: "spring onions", Aliases: []string{"scallions"}},
+	}
+	tests := []struct {
+		entries []string
+		matched []primitive.ObjectID
+		unknown []string
+	}{
+		{[]string{"tomatoes"}, []primitive.ObjectID{tomatoes}, []string{}},
+		{[]string{"Tomato "}, []primitive.ObjectID{tomatoes}, []string{}},
+		{[]string{"scallion"}, []primitive.ObjectID{onions}, []string{}},
+		{[]string{onions.Hex()}, []primitive.ObjectID{onions}, []string{}},
+		{[]string{"saffron", "tomatoes"}, []primitive.ObjectID{tomatoes}, []string{"saffron"}},
+	}
+	for _, test := range tests {
+		matched, unknown := matchIngredients(test.entries, ingredients)
+		if len(matched) != len(test.matched) {
+			t.Errorf("matchIngredients(%q) matched %v, want %v", test.entries, matched, test.matched)
+		}
+		for _, ID := range test.matched {
+			if !matched[ID] {
+				t.Errorf("matchIngredients(%q) did not match %s", test.entries, ID.Hex())
+			}
+		}
+		if !slices.Equal(unknown, test.unknown) {
+			t.Errorf("matchIngredients(%q) unknown = %q, want %q", test.entries, unknown, test.unknown)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rest/model"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errIngredientsUnavailable = errors.New("failed to load ingredients")
+
+// parseAllergens reads a comma separated list of allergens, like
+// "nuts,dairy".
+func parseAllergens(param string) ([]model.Allergen, error) {
+	var allergens []model.Allergen
+	for _, name := range strings.Split(param, ",") {
+		allergen := model.Allergen(strings.ToLower(strings.TrimSpace(name)))
+		if allergen == "" {
+			continue
+		}
+		if !allergen.IsValid() {
+			return nil, fmt.Errorf("unknown allergen %q", allergen)
+		}
+		allergens = append(allergens, allergen)
+	}
+	return allergens, nil
+}
+
+// parseDiet reads a diet, which may be empty.
+func parseDiet(param string) (model.Diet, error) {
+	diet := model.Diet(strings.ToLower(strings.TrimSpace(param)))
+	if diet != "" && !diet.IsValid() {
+		return "", fmt.Errorf("unknown diet %q, expected vegan, vegetarian or pescatarian", diet)
+	}
+	return diet, nil
+}
+
+// parseDietaryQuery reads the exclude_allergens and diet query parameters
+// and returns the only ingredients a recipe may use to match them, or nil
+// if there are none. An ingredient whose allergens are not known is left
+// out along with those that contain one of the allergens, since it cannot
+// be shown to be safe, and so is any ingredient that no longer exists.
+func (a *App) parseDietaryQuery(r *http.Request) ([]primitive.ObjectID, error) {
+	allergens, err := parseAllergens(r.URL.Query().Get("exclude_allergens"))
+	if err != nil {
+		return nil, err
+	}
+	diet, err := parseDiet(r.URL.Query().Get("diet"))
+	if err != nil {
+		return nil, err
+	}
+	if len(allergens) == 0 && diet == "" {
+		return nil, nil
+	}
+	ingredients := a.Database.AllIngredients()
+	if ingredients == nil {
+		return nil, errIngredientsUnavailable
+	}
+	allowed := []primitive.ObjectID{}
+	for _, ingredient := range ingredients {
+		unsafe := slices.ContainsFunc(allergens, ingredient.HasAllergen)
+		if unsafe || (diet != "" && !ingredient.FitsDiet(diet)) {
+			continue
+		}
+		ID, err := primitive.ObjectIDFromHex(ingredient.ID)
+		if err != nil {
+			continue
+		}
+		allowed = append(allowed, ID)
+	}
+	return allowed, nil
+}
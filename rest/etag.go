@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"rest/units"
+	"strconv"
+	"strings"
+)
+
+// recipeETag is the entity tag of a recipe at version.
+func recipeETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// recipeVariantETag is the entity tag of a recipe at version as
+// GET /recipes/{id} shows it: scaled to servings and converted to system,
+// where those are set. A scaled or converted copy gets a tag of its own, so
+// it is never taken for the recipe as stored.
+func recipeVariantETag(version int, servings int, system units.System) string {
+	if servings == 0 && system == "" {
+		return recipeETag(version)
+	}
+	tag := strconv.Itoa(version)
+	if servings > 0 {
+		tag += ";servings=" + strconv.Itoa(servings)
+	}
+	if system != "" {
+		tag += ";units=" + string(system)
+	}
+	return strconv.Quote(tag)
+}
+
+// etagMatches reports whether header, an If-Match or If-None-Match list of
+// entity tags, includes etag. If-None-Match compares weakly, ignoring the W/
+// prefix; If-Match compares strongly, so weak tags never match.
+func etagMatches(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch makes sure the caller changes the version of the recipe they
+// last saw. It writes an error response and returns false if the request
+// has no If-Match header, or one that names another version.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, recipe *model.Recipe) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		w.Write(getErrorResponse("Send the recipe's ETag in an If-Match header to change it"))
+		return false
+	}
+	etag := recipeETag(recipe.Version)
+	if !etagMatches(header, etag, false) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write(getErrorResponse("The recipe has been changed since you loaded it"))
+		return false
+	}
+	return true
+}
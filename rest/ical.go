@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// calendarEvent is one event of an iCalendar (RFC 5545) export. Start is in
+// floating time: the same wall clock time in whatever zone the calendar is
+// viewed.
+type calendarEvent struct {
+	UID         string
+	Start       time.Time
+	Duration    time.Duration
+	Summary     string
+	Description string
+}
+
+func writeCalendar(name string, events []calendarEvent) []byte {
+	var buf bytes.Buffer
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	writeCalendarLine(&buf, "BEGIN:VCALENDAR")
+	writeCalendarLine(&buf, "VERSION:2.0")
+	writeCalendarLine(&buf, "PRODID:-//cooking-recipes-backend-rest//meal plans//EN")
+	writeCalendarLine(&buf, "X-WR-CALNAME:"+escapeCalendarText(name))
+	for _, event := range events {
+		writeCalendarLine(&buf, "BEGIN:VEVENT")
+		writeCalendarLine(&buf, "UID:"+event.UID)
+		writeCalendarLine(&buf, "DTSTAMP:"+stamp)
+		writeCalendarLine(&buf, "DTSTART:"+event.Start.Format("20060102T150405"))
+		writeCalendarLine(&buf, "DTEND:"+event.Start.Add(event.Duration).Format("20060102T150405"))
+		writeCalendarLine(&buf, "SUMMARY:"+escapeCalendarText(event.Summary))
+		if event.Description != "" {
+			writeCalendarLine(&buf, "DESCRIPTION:"+escapeCalendarText(event.Description))
+		}
+		writeCalendarLine(&buf, "END:VEVENT")
+	}
+	writeCalendarLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// writeCalendarLine ends the line with CRLF and folds it so that no line is
+// longer than 75 bytes, without splitting UTF-8 sequences.
+func writeCalendarLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the
+		// limit.
+		limit = 74
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+var calendarTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+
+func escapeCalendarText(text string) string {
+	return calendarTextEscaper.Replace(text)
+}
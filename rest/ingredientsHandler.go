@@ -2,60 +2,76 @@ package rest
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"rest/database"
 	"rest/model"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
 
 // AllIngredients godoc
 // @Summary Get all ingredients
-// @Description get all ingredients
+// @Description get one page of ingredients. Follow next_cursor, or the Link header, for the next page.
 // @ID allingredients
 // @Produce json
-// @Success 200 {object} []model.Ingredient
+// @Param        sort   query      string  false  "name, -name, created (default) or -created"
+// @Param        limit   query      int  false  "Page size (default 50, max 200)"
+// @Param        after   query      string  false  "Cursor from the previous page"
+// @Success 200 {object} model.IngredientPage
 // @Router /ingredients [get]
-func getAllIngredients(w http.ResponseWriter, r *http.Request) {
+func (a *App) getAllIngredients(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var ingredients = db.AllIngredients()
-	if ingredients == nil {
-		fmt.Errorf("Failed to load ingredient as json")
+	query, err := parseListQuery(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	ingredients, more, err := a.Database.ListIngredients(query)
+	if err != nil {
+		log.Print("Failed to load ingredients: ", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Failed to load ingredient"))
 		return
 	}
-	if len(ingredients) == 0 {
-		emptyList := make([]model.Ingredient, 0)
-		emptyData, _ := loadDataAsJSON(emptyList)
-		w.Write(emptyData)
-		return
+	if ingredients == nil {
+		ingredients = []*model.Ingredient{}
+	}
+	page := model.IngredientPage{Data: ingredients}
+	if more {
+		last := ingredients[len(ingredients)-1]
+		page.NextCursor = encodeCursor(model.ListCursor{Name: last.Name, ID: last.ID})
+		setNextLink(w, r, page.NextCursor)
 	}
-	data, err := loadDataAsJSON(ingredients)
+	data, err := loadDataAsJSON(page)
 	if err != nil {
-		fmt.Errorf("Failed to load ingredient as json")
+		log.Print("Failed to load ingredient as json: ", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Could not load ingredient"))
+		return
 	}
 	w.Write(data)
 }
 
 // GetIngredientByName godoc
 // @Summary Get ingredient by name
-// @Description get ingredient by name
+// @Description get an ingredient by its name or one of its aliases, ignoring case and plurals
 // @ID ingredientbyname
 // @Produce json
 // @Success 200 {object} model.Ingredient
 // @Param        name   path      string  true  "Name"
 // @Router /ingredients/{name} [get]
-func getIngredientByName(w http.ResponseWriter, r *http.Request) {
+func (a *App) getIngredientByName(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	nameParam := chi.URLParam(r, "name")
-	var ingredient = db.FindIngredientByName(nameParam)
+	var ingredient = a.Database.FindIngredientByName(nameParam)
 	if ingredient == nil {
-		fmt.Errorf("Failed to get ingredient")
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(getErrorResponse("Failed to get ingredient"))
 		return
@@ -63,23 +79,71 @@ func getIngredientByName(w http.ResponseWriter, r *http.Request) {
 
 	data, err := loadDataAsJSON(ingredient)
 	if err != nil {
-		fmt.Errorf("Failed to load ingredient as json")
+		log.Print("Failed to load ingredient as json: ", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Could not load ingredient"))
 	}
 	w.Write(data)
 }
 
+// maxLookupCandidates is how many close matches a lookup returns by
+// default, and the most an added ingredient warns about.
+const maxLookupCandidates = 5
+
+// LookupIngredients godoc
+// @Summary Find ingredients by an approximate name
+// @Description find the ingredients whose name or alias is close to a name, allowing for case, plurals and typos, closest first
+// @ID lookupingredients
+// @Produce json
+// @Param        name   query      string  true  "Name to look up"
+// @Param        limit   query      int  false  "Number of candidates (default 5, max 50)"
+// @Success 200 {object} []model.IngredientCandidate
+// @Failure 400 {object} ErrorResponse
+// @Router /ingredients/lookup [get]
+func (a *App) LookupIngredients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	name := r.URL.Query().Get("name")
+	if strings.TrimSpace(name) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("name is required"))
+		return
+	}
+	limit := maxLookupCandidates
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > 50 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("limit must be between 1 and 50"))
+			return
+		}
+		limit = parsed
+	}
+	ingredients := a.Database.AllIngredients()
+	if ingredients == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load ingredients"))
+		return
+	}
+	data, err := loadDataAsJSON(model.RankIngredients(name, ingredients, limit))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load ingredients"))
+		return
+	}
+	w.Write(data)
+}
+
 // AddIngredient godoc
 // @Summary Add an ingredient
-// @Description Add ingredient
+// @Description add an ingredient. Names and aliases must differ from those of other ingredients in more than case and plurals. When the name is close to an existing one, the ingredient is still added, with a warning and the close matches in did_you_mean.
 // @ID addingredient
 // @Produce json
 // Accept json
 // @Param  ingredient   body  model.IngredientWithoutID  true  "Ingredient"
-// @Success 201 {object} model.Ingredient
+// @Success 201 {object} model.AddedIngredient
+// @Failure 409 {object} ErrorResponse
 // @Router /ingredients [post]
-func AddIngredient(w http.ResponseWriter, r *http.Request) {
+func (a *App) AddIngredient(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var body model.IngredientWithoutID
 
@@ -89,32 +153,314 @@ func AddIngredient(w http.ResponseWriter, r *http.Request) {
 		w.Write(getErrorResponse("Failed to decode ingredient"))
 		return
 	}
-	existingIngredient := db.FindIngredientByName(body.Name)
-	if existingIngredient != nil {
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = currentUser(r).ID
+	if existing := a.ingredientWithSameName(&body, ""); existing != nil {
 		w.WriteHeader(http.StatusConflict)
-		w.Write(getErrorResponse("An ingredient with this name already exists"))
+		w.Write(getErrorResponse("An ingredient with this name already exists: " + existing.Name))
 		return
 	}
-	newIngredient := db.SaveIngredient(&body)
-	data, err := loadDataAsJSON(newIngredient)
+	similar := model.RankIngredients(body.Name, a.Database.AllIngredients(), maxLookupCandidates)
+	newIngredient := a.Database.SaveIngredient(&body)
+	if newIngredient == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save ingredient"))
+		return
+	}
+	added := model.AddedIngredient{Ingredient: *newIngredient}
+	if len(similar) > 0 {
+		names := make([]string, len(similar))
+		for i, candidate := range similar {
+			names[i] = candidate.Ingredient.Name
+		}
+		added.Warning = "Did you mean " + strings.Join(names, " or ") + "?"
+		added.DidYouMean = similar
+	}
+	data, err := loadDataAsJSON(added)
 	if err != nil {
 		w.Write(getErrorResponse("Failed to load ingredient"))
-		fmt.Errorf("Failed to load ingredient as json")
+		log.Print("Failed to load ingredient as json: ", err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
 	w.Write(data)
 }
 
+// ReplaceIngredient godoc
+// @Summary Replace an ingredient
+// @Description replace an ingredient, for example to rename it
+// @ID replaceingredient
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Ingredient ID"
+// @Param  ingredient   body  model.IngredientWithoutID  true  "Ingredient"
+// @Success 200 {object} model.Ingredient
+// @Router /ingredients/{id} [put]
+func (a *App) ReplaceIngredient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	var body model.IngredientWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode ingredient"))
+		return
+	}
+	ingredient := a.ingredientForChange(w, r, idParam)
+	if ingredient == nil {
+		return
+	}
+	body.OwnerID = ingredient.OwnerID
+	a.writeUpdatedIngredient(w, idParam, &body)
+}
+
+// PatchIngredient godoc
+// @Summary Update an ingredient
+// @Description update some fields of an ingredient using JSON Merge Patch (RFC 7386)
+// @ID patchingredient
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Ingredient ID"
+// @Param  patch   body  model.IngredientWithoutID  true  "Merge patch"
+// @Success 200 {object} model.Ingredient
+// @Router /ingredients/{id} [patch]
+func (a *App) PatchIngredient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	ingredient := a.ingredientForChange(w, r, idParam)
+	if ingredient == nil {
+		return
+	}
+	original, err := loadDataAsJSON(ingredient.WithoutID())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load ingredient"))
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to read patch"))
+		return
+	}
+	patched, err := applyMergePatch(original, patch)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode patch"))
+		return
+	}
+	var body model.IngredientWithoutID
+	if err := json.Unmarshal(patched, &body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Patch does not produce a valid ingredient"))
+		return
+	}
+	body.OwnerID = ingredient.OwnerID
+	a.writeUpdatedIngredient(w, idParam, &body)
+}
+
+// ingredientForChange loads an ingredient the caller wants to change. It
+// writes an error response and returns nil if there is no such ingredient
+// or the caller may not change it.
+func (a *App) ingredientForChange(w http.ResponseWriter, r *http.Request, ID string) *model.Ingredient {
+	ingredient := a.Database.FindIngredientByID(ID)
+	if ingredient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find ingredient"))
+		return nil
+	}
+	if !canModify(currentUser(r), ingredient.OwnerID) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse("Only the owner of the ingredient or an admin can change it"))
+		return nil
+	}
+	return ingredient
+}
+
+func (a *App) writeUpdatedIngredient(w http.ResponseWriter, ID string, body *model.IngredientWithoutID) {
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	if existing := a.ingredientWithSameName(body, ID); existing != nil {
+		w.WriteHeader(http.StatusConflict)
+		w.Write(getErrorResponse("An ingredient with this name already exists: " + existing.Name))
+		return
+	}
+	ingredient, err := a.Database.UpdateIngredient(ID, body)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find ingredient"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update ingredient"))
+		return
+	}
+	data, err := loadDataAsJSON(ingredient)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load ingredient"))
+		return
+	}
+	w.Write(data)
+}
+
+// ingredientWithSameName returns an ingredient other than the one with ID
+// that already has the name or one of the aliases of body.
+func (a *App) ingredientWithSameName(body *model.IngredientWithoutID, ID string) *model.Ingredient {
+	for _, name := range append([]string{body.Name}, body.Aliases...) {
+		if existing := a.Database.FindIngredientByName(name); existing != nil && existing.ID != ID {
+			return existing
+		}
+	}
+	return nil
+}
+
+type IngredientInUseResponse struct {
+	Error         string                `json:"error"`
+	Recipes       []model.RecipeSummary `json:"recipes"`
+	PantryItems   int                   `json:"pantry_items"`
+	Substitutions int                   `json:"substitutions"`
+}
+
+// DeleteIngredient godoc
+// @Summary Delete an ingredient
+// @Description delete an ingredient. Ingredients used by recipes, pantry items or substitutions are only deleted with force=cascade, which also removes them from those recipes and deletes the pantry items and substitutions.
+// @ID deleteingredient
+// @Produce json
+// @Param        id   path      string  true  "Ingredient ID"
+// @Param        force   query      string  false  "Set to cascade to remove the ingredient from recipes, the pantry and substitutions"
+// @Success 204
+// @Failure 409 {object} IngredientInUseResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ingredients/{id} [delete]
+func (a *App) DeleteIngredient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	force := r.URL.Query().Get("force")
+	if force != "" && force != "cascade" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("force must be cascade"))
+		return
+	}
+	ingredient := a.ingredientForChange(w, r, idParam)
+	if ingredient == nil {
+		return
+	}
+
+	recipes, err := a.Database.RecipesUsingIngredient(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to find recipes that use the ingredient"))
+		return
+	}
+	items, err := a.Database.PantryItemsUsingIngredient(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to find pantry items of the ingredient"))
+		return
+	}
+	substitutions, err := a.Database.SubstitutionsUsingIngredient(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to find substitutions of the ingredient"))
+		return
+	}
+	if (len(recipes) > 0 || len(items) > 0 || len(substitutions) > 0) && force != "cascade" {
+		response := IngredientInUseResponse{
+			Error:         "The ingredient is used by recipes, pantry items or substitutions",
+			Recipes:       make([]model.RecipeSummary, 0, len(recipes)),
+			PantryItems:   len(items),
+			Substitutions: len(substitutions),
+		}
+		for _, recipe := range recipes {
+			response.Recipes = append(response.Recipes, model.RecipeSummary{ID: recipe.ID, Name: recipe.Name})
+		}
+		data, _ := loadDataAsJSON(response)
+		w.WriteHeader(http.StatusConflict)
+		w.Write(data)
+		return
+	}
+	for _, recipe := range recipes {
+		if !canModify(currentUser(r), recipe.OwnerID) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(getErrorResponse("The ingredient is used by recipes you cannot change"))
+			return
+		}
+	}
+	for _, substitution := range substitutions {
+		if !canModify(currentUser(r), substitution.OwnerID) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(getErrorResponse("The ingredient is used by substitutions you cannot change"))
+			return
+		}
+	}
+	if len(items) > 0 && !checkScope(w, r, model.ScopePlannerWrite) {
+		return
+	}
+	if len(recipes) > 0 || len(items) > 0 || len(substitutions) > 0 {
+		err = a.Database.DeleteIngredientCascade(idParam)
+		if errors.Is(err, database.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(getErrorResponse("Could not find ingredient"))
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write(getErrorResponse("A recipe changed while the ingredient was being deleted, which was undone. Try again"))
+			return
+		}
+		if err != nil {
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(getErrorResponse("Failed to delete ingredient"))
+			return
+		}
+		for _, recipe := range recipes {
+			if updated := a.Database.FindRecipeDocumentByID(recipe.ID); updated != nil {
+				after := updated.WithoutID()
+				a.recordRevision(r, recipe.ID, recipe, &after, "Removed ingredient "+ingredient.Name)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	deleted, err := a.Database.DeleteIngredient(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete ingredient"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find ingredient"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GenerateIngredients godoc
 // @Summary Generate ingredients
-// @Description Generate ingredients
+// @Description Generate ingredients from the test data. Admin only, and only available when the server runs with PROFILE=development.
 // @ID generateingredients
 // @Produce json
 // Accept json
 // @Success 201 {object} []model.Ingredient
 // @Router /ingredients/generate [post]
-func GenerateIngredients(w http.ResponseWriter, r *http.Request) {
+func (a *App) GenerateIngredients(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	ingredientsCreated := make([]*model.Ingredient, 0)
@@ -123,7 +469,7 @@ func GenerateIngredients(w http.ResponseWriter, r *http.Request) {
 	fileContent, err := os.Open(filename)
 
 	if err != nil {
-		fmt.Errorf("failed to open file")
+		log.Print("failed to open file: ", err)
 		w.Write(getErrorResponse("Failed to open testdata file"))
 	}
 
@@ -135,12 +481,9 @@ func GenerateIngredients(w http.ResponseWriter, r *http.Request) {
 	json.Unmarshal([]byte(byteResult), &testData)
 
 	for _, ingredient := range testData {
-		ingredientCopy := model.Ingredient{
-			ID:   ingredient.ID,
-			Name: ingredient.Name,
-		}
+		ingredientCopy := ingredient
 		ingredientsCreated = append(ingredientsCreated, &ingredient)
-		db.SaveIngredientWithID(&ingredientCopy)
+		a.Database.SaveIngredientWithID(&ingredientCopy)
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteIngredientCascade(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var butter, flour, margarine model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "butter"}, &butter)
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour"}, &flour)
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "margarine"}, &margarine)
+	butterID, _ := primitive.ObjectIDFromHex(butter.ID)
+	flourID, _ := primitive.ObjectIDFromHex(flour.ID)
+	margarineID, _ := primitive.ObjectIDFromHex(margarine.ID)
+	var recipe model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:     "Shortbread",
+		Category: model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{
+			{Ingredient: butterID, IngredientMeta: model.IngredientMeta{Quantity: 100, Unit: "g"}},
+			{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 200, Unit: "g"}},
+		},
+	}, &recipe)
+	var item model.PantryItem
+	request(t, app, "POST", "/pantry", admin, "", model.PantryItemWithoutID{Ingredient: butterID, Quantity: 250, Unit: "g"}, &item)
+	var substitution model.Substitution
+	request(t, app, "POST", "/substitutions", admin, "", model.SubstitutionWithoutID{Ingredient: butterID, Replacements: []model.Replacement{{Ingredient: margarineID, Ratio: 1}}}, &substitution)
+
+	if w := request(t, app, "DELETE", "/ingredients/"+butter.ID, admin, "", nil, nil); w.Code != http.StatusConflict {
+		t.Fatalf("deleting without force = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := request(t, app, "DELETE", "/ingredients/"+butter.ID+"?force=cascade", admin, "", nil, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("deleting with force=cascade = %d %s", w.Code, w.Body)
+	}
+
+	if app.Database.FindIngredientByID(butter.ID) != nil {
+		t.Error("ingredient was not deleted")
+	}
+	updated := app.Database.FindRecipeDocumentByID(recipe.ID)
+	if len(updated.IngredientLines) != 1 || updated.IngredientLines[0].Ingredient != flourID || updated.Version != recipe.Version+1 {
+		t.Errorf("recipe = %+v, want only the flour at the next version", updated)
+	}
+	if app.Database.FindPantryItemByID(item.ID) != nil {
+		t.Error("pantry item was not deleted")
+	}
+	if app.Database.FindSubstitutionByID(substitution.ID) != nil {
+		t.Error("substitution was not deleted")
+	}
+	var revisions []model.RecipeRevision
+	request(t, app, "GET", "/recipes/"+recipe.ID+"/revisions", admin, "", nil, &revisions)
+	if len(revisions) == 0 || revisions[len(revisions)-1].Message != "Removed ingredient butter" {
+		t.Errorf("revisions = %+v, want one for the removed ingredient", revisions)
+	}
+}
@@ -0,0 +1,423 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"rest/database"
+	"rest/model"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// mealTimes are the start times of meal slots in calendar exports, as
+// offsets from midnight.
+var mealTimes = map[model.MealSlot]time.Duration{
+	model.Breakfast: 8 * time.Hour,
+	model.Lunch:     12*time.Hour + 30*time.Minute,
+	model.Snack:     16 * time.Hour,
+	model.Dinner:    19 * time.Hour,
+}
+
+// AllMealPlans godoc
+// @Summary Get all meal plans
+// @Description get the caller's meal plans, or every plan for admins
+// @ID allmealplans
+// @Produce json
+// @Success 200 {object} []model.MealPlan
+// @Router /meal-plans [get]
+func (a *App) getAllMealPlans(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	plans := a.Database.AllMealPlans()
+	if plans == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load meal plans"))
+		return
+	}
+	user := currentUser(r)
+	plans = slices.DeleteFunc(plans, func(plan *model.MealPlan) bool {
+		return !canModify(user, plan.OwnerID)
+	})
+	data, err := loadDataAsJSON(plans)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load meal plans"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetMealPlan godoc
+// @Summary Get meal plan by ID
+// @Description get a meal plan by ID
+// @ID getmealplan
+// @Produce json
+// @Param        id   path      string  true  "Meal plan ID"
+// @Success 200 {object} model.MealPlan
+// @Router /meal-plans/{id} [get]
+func (a *App) getMealPlanByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+	data, err := loadDataAsJSON(plan)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load meal plan"))
+		return
+	}
+	w.Write(data)
+}
+
+// findMealPlan loads the meal plan in the URL if the caller owns it. Other
+// users' plans are reported as missing, like their API keys. It writes an
+// error response and returns nil if there is no such plan.
+func (a *App) findMealPlan(w http.ResponseWriter, r *http.Request) *model.MealPlan {
+	plan := a.Database.FindMealPlanByID(chi.URLParam(r, "id"))
+	if plan == nil || !canModify(currentUser(r), plan.OwnerID) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find meal plan"))
+		return nil
+	}
+	return plan
+}
+
+// AddMealPlan godoc
+// @Summary Add a meal plan
+// @Description add a meal plan: recipes on a calendar of days and meal slots (breakfast, lunch, snack, dinner)
+// @ID addmealplan
+// @Produce json
+// Accept json
+// @Param  plan   body  model.MealPlanWithoutID  true  "Meal plan"
+// @Success 201 {object} model.MealPlan
+// @Failure 400 {object} ErrorResponse
+// @Router /meal-plans [post]
+func (a *App) AddMealPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.MealPlanWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode meal plan"))
+		return
+	}
+	if err := a.validateMealPlan(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = currentUser(r).ID
+	plan, err := a.Database.SaveMealPlan(&body)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save meal plan"))
+		return
+	}
+	data, err := loadDataAsJSON(plan)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load meal plan"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// ReplaceMealPlan godoc
+// @Summary Replace a meal plan
+// @Description replace the name and every entry of a meal plan
+// @ID replacemealplan
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Meal plan ID"
+// @Param  plan   body  model.MealPlanWithoutID  true  "Meal plan"
+// @Success 200 {object} model.MealPlan
+// @Failure 400 {object} ErrorResponse
+// @Router /meal-plans/{id} [put]
+func (a *App) ReplaceMealPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.MealPlanWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode meal plan"))
+		return
+	}
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+	if err := a.validateMealPlan(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = plan.OwnerID
+	a.writeUpdatedMealPlan(w, plan.ID, &body)
+}
+
+func (a *App) writeUpdatedMealPlan(w http.ResponseWriter, ID string, body *model.MealPlanWithoutID) {
+	plan, err := a.Database.UpdateMealPlan(ID, body)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find meal plan"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update meal plan"))
+		return
+	}
+	data, err := loadDataAsJSON(plan)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load meal plan"))
+		return
+	}
+	w.Write(data)
+}
+
+// validateMealPlan checks the plan itself and that every recipe it points to
+// exists.
+func (a *App) validateMealPlan(plan *model.MealPlanWithoutID) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	checked := map[string]bool{}
+	for i, entry := range plan.Entries {
+		if checked[entry.RecipeID] {
+			continue
+		}
+		if a.Database.FindRecipeDocumentByID(entry.RecipeID) == nil {
+			return fmt.Errorf("entries[%d]: unknown recipe %s", i, entry.RecipeID)
+		}
+		checked[entry.RecipeID] = true
+	}
+	return nil
+}
+
+// DeleteMealPlan godoc
+// @Summary Delete a meal plan
+// @Description delete a meal plan
+// @ID deletemealplan
+// @Param        id   path      string  true  "Meal plan ID"
+// @Success 204
+// @Router /meal-plans/{id} [delete]
+func (a *App) DeleteMealPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+	deleted, err := a.Database.DeleteMealPlan(plan.ID)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete meal plan"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find meal plan"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CopyMealPlanWeek godoc
+// @Summary Copy a week of a meal plan
+// @Description copy the entries of one week, Monday to Sunday, to the week after it. Entries already planned in the next week are kept, and are not copied twice.
+// @ID copymealplanweek
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Meal plan ID"
+// @Param  week   body  model.CopyWeekRequest  true  "Any date in the week to copy"
+// @Success 200 {object} model.MealPlan
+// @Router /meal-plans/{id}/copy-week [post]
+func (a *App) CopyMealPlanWeek(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.CopyWeekRequest
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode week"))
+		return
+	}
+	day, err := time.Parse(time.DateOnly, body.Week)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("week must be a date in YYYY-MM-DD form"))
+		return
+	}
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+	updated := model.MealPlanWithoutID{Name: plan.Name, Entries: copyWeek(plan.Entries, day), OwnerID: plan.OwnerID}
+	if err := updated.Validate(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	a.writeUpdatedMealPlan(w, plan.ID, &updated)
+}
+
+// copyWeek returns entries with the entries of the week containing day
+// added again seven days later, unless the same entry is already there.
+func copyWeek(entries []model.MealPlanEntry, day time.Time) []model.MealPlanEntry {
+	// time.Weekday counts from Sunday, weeks here start on Monday.
+	monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	nextMonday := monday.AddDate(0, 0, 7)
+	copied := slices.Clone(entries)
+	for _, entry := range entries {
+		date, err := entry.Day()
+		if err != nil || date.Before(monday) || !date.Before(nextMonday) {
+			continue
+		}
+		entry.Date = date.AddDate(0, 0, 7).Format(time.DateOnly)
+		if !slices.Contains(copied, entry) {
+			copied = append(copied, entry)
+		}
+	}
+	return copied
+}
+
+// ExportMealPlan godoc
+// @Summary Export a meal plan as a calendar
+// @Description export a meal plan as an iCalendar (.ics) file with one event per meal
+// @ID exportmealplan
+// @Produce text/calendar
+// @Param        id   path      string  true  "Meal plan ID"
+// @Success 200 {string} string
+// @Router /meal-plans/{id}/calendar.ics [get]
+func (a *App) ExportMealPlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+	recipes := map[string]*model.Recipe{}
+	events := make([]calendarEvent, 0, len(plan.Entries))
+	for i, entry := range plan.Entries {
+		day, err := entry.Day()
+		if err != nil {
+			continue
+		}
+		recipe, ok := recipes[entry.RecipeID]
+		if !ok {
+			recipe = a.Database.FindRecipeDocumentByID(entry.RecipeID)
+			recipes[entry.RecipeID] = recipe
+		}
+		name := "Deleted recipe"
+		description := ""
+		if recipe != nil {
+			name = recipe.Name
+			description = recipe.Description
+		}
+		summary := fmt.Sprintf("%s: %s", capitalize(string(entry.Slot)), name)
+		if servings := entryServings(entry, recipe); servings > 0 {
+			summary += fmt.Sprintf(" (%d servings)", servings)
+		}
+		events = append(events, calendarEvent{
+			UID:         fmt.Sprintf("%s-%d-%s-%s@recipes", plan.ID, i, entry.Date, entry.Slot),
+			Start:       day.Add(mealTimes[entry.Slot]),
+			Duration:    time.Hour,
+			Summary:     summary,
+			Description: description,
+		})
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"meal-plan-%s.ics\"", plan.ID))
+	w.Write(writeCalendar(plan.Name, events))
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// entryServings is how many servings an entry plans for: its own count, or
+// what the recipe makes.
+func entryServings(entry model.MealPlanEntry, recipe *model.Recipe) int {
+	if entry.Servings > 0 || recipe == nil {
+		return entry.Servings
+	}
+	return recipe.Servings
+}
+
+// MealPlanShoppingList godoc
+// @Summary Make a shopping list from a meal plan
+// @Description create a saved shopping list with everything needed for the meals in a plan, optionally only those between two dates. Meals whose recipe was deleted are left out and their recipe IDs listed under deleted_recipes.
+// @ID mealplanshoppinglist
+// @Produce json
+// @Param        id   path      string  true  "Meal plan ID"
+// @Param        from   query      string  false  "First day to shop for, YYYY-MM-DD"
+// @Param        to   query      string  false  "Last day to shop for, YYYY-MM-DD"
+// @Success 201 {object} model.ShoppingList
+// @Failure 400 {object} ErrorResponse
+// @Router /meal-plans/{id}/shopping-list [post]
+func (a *App) MealPlanShoppingList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("from and to must be dates in YYYY-MM-DD form"))
+			return
+		}
+	}
+	plan := a.findMealPlan(w, r)
+	if plan == nil {
+		return
+	}
+
+	var recipes []model.ShoppingListRecipe
+	var deleted []string
+	for _, entry := range plan.Entries {
+		// Dates are YYYY-MM-DD, so they compare like strings.
+		if from != "" && entry.Date < from || to != "" && entry.Date > to {
+			continue
+		}
+		recipe := a.Database.FindRecipeDocumentByID(entry.RecipeID)
+		if recipe == nil {
+			// Like the calendar export, leave out meals whose recipe was
+			// deleted instead of failing the whole list.
+			if !slices.Contains(deleted, entry.RecipeID) {
+				deleted = append(deleted, entry.RecipeID)
+			}
+			continue
+		}
+		multiplier := 1.0
+		if entry.Servings > 0 && recipe.Servings > 0 {
+			multiplier = float64(entry.Servings) / float64(recipe.Servings)
+		}
+		recipes = append(recipes, model.ShoppingListRecipe{RecipeID: entry.RecipeID, Multiplier: multiplier})
+	}
+	if len(recipes) == 0 && len(deleted) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("The meal plan has no meals in this period"))
+		return
+	}
+	list, err := a.buildShoppingList(recipes)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	list.MealPlanID = plan.ID
+	list.DeletedRecipes = deleted
+	a.writeNewShoppingList(w, r, list)
+}
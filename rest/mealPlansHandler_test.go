@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMealPlanShoppingListDeletedRecipe(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var flour model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour"}, &flour)
+	flourID, _ := primitive.ObjectIDFromHex(flour.ID)
+	var bread, cake model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:            "Bread",
+		Category:        model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 500, Unit: "g"}}},
+	}, &bread)
+	w := request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:            "Cake",
+		Category:        model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 200, Unit: "g"}}},
+	}, &cake)
+	var plan model.MealPlan
+	request(t, app, "POST", "/meal-plans", admin, "", model.MealPlanWithoutID{Name: "Week", Entries: []model.MealPlanEntry{
+		{Date: "2026-01-05", Slot: model.Dinner, RecipeID: bread.ID},
+		{Date: "2026-01-06", Slot: model.Dinner, RecipeID: cake.ID},
+		{Date: "2026-01-07", Slot: model.Dinner, RecipeID: cake.ID},
+	}}, &plan)
+	if w := request(t, app, "DELETE", "/recipes/"+cake.ID, admin, w.Header().Get("ETag"), nil, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("deleting recipe: %d %s", w.Code, w.Body)
+	}
+
+	var list model.ShoppingList
+	if w := request(t, app, "POST", "/meal-plans/"+plan.ID+"/shopping-list", admin, "", nil, &list); w.Code != http.StatusCreated {
+		t.Fatalf("shopping list = %d %s, want %d", w.Code, w.Body, http.StatusCreated)
+	}
+	if len(list.Aisles) != 1 || len(list.Aisles[0].Items) != 1 || list.Aisles[0].Items[0].Quantity != 500 {
+		t.Errorf("aisles = %+v, want only the bread's flour", list.Aisles)
+	}
+	if !slices.Equal(list.DeletedRecipes, []string{cake.ID}) {
+		t.Errorf("DeletedRecipes = %v, want [%s]", list.DeletedRecipes, cake.ID)
+	}
+}
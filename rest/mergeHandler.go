@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"rest/database"
+	"rest/model"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MergeIngredients godoc
+// @Summary Merge duplicate ingredients
+// @Description merge duplicates into an ingredient. Recipes, including those in the trash, pantry items and substitutions that use a duplicate are changed to use the ingredient, the names of the duplicates become its aliases, and the duplicates are deleted. With dry_run nothing changes, and the response lists what would.
+// @ID mergeingredients
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "ID of the ingredient to keep"
+// @Param  request   body  model.MergeIngredientsRequest  true  "Duplicates"
+// @Success 200 {object} model.IngredientMerge
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /ingredients/{id}/merge [post]
+func (a *App) MergeIngredients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.MergeIngredientsRequest
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode merge"))
+		return
+	}
+	canonical := a.Database.FindIngredientByID(chi.URLParam(r, "id"))
+	if canonical == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find ingredient"))
+		return
+	}
+	if len(body.Duplicates) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("duplicates are required"))
+		return
+	}
+	var duplicates []*model.Ingredient
+	var duplicateIDs []string
+	for _, ID := range body.Duplicates {
+		if ID == canonical.ID {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("An ingredient cannot be merged into itself"))
+			return
+		}
+		if slices.Contains(duplicateIDs, ID) {
+			continue
+		}
+		duplicate := a.Database.FindIngredientByID(ID)
+		if duplicate == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Unknown duplicate " + ID))
+			return
+		}
+		duplicates = append(duplicates, duplicate)
+		duplicateIDs = append(duplicateIDs, ID)
+	}
+	merged := model.MergedIngredient(canonical, duplicates)
+	if err := merged.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("The merged ingredient is not valid: " + err.Error()))
+		return
+	}
+
+	result, recipes, err := a.mergeEffects(canonical.ID, duplicateIDs)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to find what uses the duplicates"))
+		return
+	}
+	result.Merged = duplicates
+	result.Ingredient = merged.WithID(canonical.ID)
+	result.DryRun = body.DryRun
+
+	if !body.DryRun {
+		err = a.Database.MergeIngredients(canonical.ID, duplicateIDs, &merged)
+		if errors.Is(err, database.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(getErrorResponse("Could not find ingredient"))
+			return
+		}
+		if errors.Is(err, database.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write(getErrorResponse("A recipe changed during the merge, which was undone. Try again"))
+			return
+		}
+		if err != nil {
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(getErrorResponse("Failed to merge ingredients"))
+			return
+		}
+		names := make([]string, len(duplicates))
+		for i, duplicate := range duplicates {
+			names[i] = duplicate.Name
+		}
+		for _, recipe := range recipes {
+			if updated := a.Database.FindRecipeDocumentByID(recipe.ID); updated != nil {
+				after := updated.WithoutID()
+				a.recordRevision(r, recipe.ID, recipe, &after, "Merged "+joinNames(names)+" into "+canonical.Name)
+			}
+		}
+	}
+
+	data, err := loadDataAsJSON(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load merge"))
+		return
+	}
+	w.Write(data)
+}
+
+// mergeEffects finds the recipes, pantry items and substitutions that merging
+// the duplicates into the ingredient with ID changes. It also returns the
+// recipes, as they are before the merge.
+func (a *App) mergeEffects(ID string, duplicates []string) (*model.IngredientMerge, []*model.Recipe, error) {
+	result := &model.IngredientMerge{Recipes: []model.RecipeSummary{}}
+	var recipes []*model.Recipe
+	for _, duplicate := range duplicates {
+		using, err := a.Database.RecipesUsingIngredient(duplicate)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, recipe := range using {
+			if !slices.ContainsFunc(recipes, func(found *model.Recipe) bool { return found.ID == recipe.ID }) {
+				recipes = append(recipes, recipe)
+				result.Recipes = append(result.Recipes, model.RecipeSummary{ID: recipe.ID, Name: recipe.Name})
+			}
+		}
+	}
+
+	canonicalID, _ := primitive.ObjectIDFromHex(ID)
+	duplicateIDs := map[primitive.ObjectID]bool{}
+	for _, duplicate := range duplicates {
+		duplicateID, _ := primitive.ObjectIDFromHex(duplicate)
+		duplicateIDs[duplicateID] = true
+	}
+	items := a.Database.AllPantryItems()
+	substitutions := a.Database.AllSubstitutions()
+	if items == nil || substitutions == nil {
+		return nil, nil, errors.New("failed to load pantry or substitutions")
+	}
+	for _, item := range items {
+		if duplicateIDs[item.Ingredient] {
+			result.PantryItems++
+		}
+	}
+	for _, substitution := range substitutions {
+		if changed, keep := substitution.ReplaceIngredients(duplicateIDs, canonicalID); changed || !keep {
+			result.Substitutions++
+		}
+	}
+	return result, recipes, nil
+}
+
+// joinNames lists names as "a", "a and b" or "a, b and c".
+func joinNames(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	last := len(names) - 1
+	joined := names[0]
+	for _, name := range names[1:last] {
+		joined += ", " + name
+	}
+	return joined + " and " + names[last]
+}
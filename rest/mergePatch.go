@@ -0,0 +1,36 @@
+package rest
+
+import "encoding/json"
+
+// applyMergePatch applies a JSON Merge Patch (RFC 7386) to the original
+// document and returns the patched document.
+func applyMergePatch(original []byte, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(original, &target); err != nil {
+		return nil, err
+	}
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(target, patchValue))
+}
+
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
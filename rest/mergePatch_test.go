@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	// The examples from RFC 7386, Appendix A.
+	tests := []struct {
+		original string
+		patch    string
+		want     string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		got, err := applyMergePatch([]byte(test.original), []byte(test.patch))
+		if err != nil {
+			t.Errorf("applyMergePatch(%s, %s) returned error %v", test.original, test.patch, err)
+			continue
+		}
+		if !jsonEqual(t, got, []byte(test.want)) {
+			t.Errorf("applyMergePatch(%s, %s) = %s, want %s", test.original, test.patch, got, test.want)
+		}
+	}
+}
+
+func TestApplyMergePatchInvalid(t *testing.T) {
+	if _, err := applyMergePatch([]byte(`{"a":"b"}`), []byte(`{"a":`)); err == nil {
+		t.Error("applyMergePatch with a malformed patch returned no error")
+	}
+	if _, err := applyMergePatch([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Error("applyMergePatch with a malformed original returned no error")
+	}
+}
+
+// jsonEqual reports whether a and b encode the same JSON value.
+func jsonEqual(t *testing.T, a []byte, b []byte) bool {
+	t.Helper()
+	var aValue, bValue any
+	if err := json.Unmarshal(a, &aValue); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &bValue); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	aCanonical, _ := json.Marshal(aValue)
+	bCanonical, _ := json.Marshal(bValue)
+	return string(aCanonical) == string(bCanonical)
+}
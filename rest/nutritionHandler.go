@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"rest/units"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RecipeNutrition godoc
+// @Summary Get the nutritional value of a recipe
+// @Description add up the nutrition facts of a recipe's ingredients, in total and per serving. Ingredient lines without nutrition data, without a quantity, or in a unit that cannot be converted to grams are not counted and are listed in missing, as are lines whose ingredient was deleted.
+// @ID recipenutrition
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Success 200 {object} model.RecipeNutrition
+// @Failure 404 {object} ErrorResponse
+// @Router /recipes/{id}/nutrition [get]
+func (a *App) RecipeNutrition(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	recipe := a.Database.FindRecipeByID(chi.URLParam(r, "id"))
+	if recipe == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	data, err := loadDataAsJSON(recipeNutrition(recipe, a.unresolvedLines(recipe)))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load nutrition"))
+		return
+	}
+	w.Write(data)
+}
+
+// recipeNutrition adds up the nutrition of every ingredient line that has
+// nutrition data and an amount that can be expressed in grams. unresolved
+// are the recipe's lines whose ingredient was deleted.
+func recipeNutrition(recipe *model.ResolvedRecipe, unresolved []model.IngredientLine) model.RecipeNutrition {
+	result := model.RecipeNutrition{
+		Recipe:   model.RecipeSummary{ID: recipe.ID, Name: recipe.Name},
+		Servings: recipe.Servings,
+		Missing:  []model.NutritionGap{},
+	}
+	for _, line := range unresolved {
+		result.Missing = append(result.Missing, model.NutritionGap{
+			IngredientID: line.Ingredient.Hex(),
+			Quantity:     line.Quantity,
+			Unit:         line.Unit,
+			Reason:       model.GapUnknownIngredient,
+		})
+	}
+	for _, line := range recipe.IngredientLines {
+		gap := model.NutritionGap{
+			IngredientID: line.Ingredient.ID,
+			Name:         line.Ingredient.Name,
+			Quantity:     line.Quantity,
+			Unit:         line.Unit,
+		}
+		if line.Ingredient.Nutrition == nil {
+			gap.Reason = model.GapNoData
+			result.Missing = append(result.Missing, gap)
+			continue
+		}
+		if line.Quantity <= 0 {
+			gap.Reason = model.GapNoQuantity
+			result.Missing = append(result.Missing, gap)
+			continue
+		}
+		grams, err := units.Convert(units.Quantity{Amount: line.Quantity, Unit: line.Unit}, "g", line.Ingredient.Density)
+		if err != nil {
+			gap.Reason = model.GapUnconvertible
+			result.Missing = append(result.Missing, gap)
+			continue
+		}
+		result.Total.Add(*line.Ingredient.Nutrition, grams.Amount/100)
+	}
+	// The unresolved lines may not all have been found, if the recipe
+	// changed since it was resolved, so the count decides.
+	result.Complete = len(result.Missing) == 0 && recipe.Classification.Unresolved == 0
+	if recipe.Servings > 0 {
+		perServing := model.Nutrition{}
+		perServing.Add(result.Total, 1/float64(recipe.Servings))
+		perServing.Round()
+		result.PerServing = &perServing
+	}
+	result.Total.Round()
+	return result
+}
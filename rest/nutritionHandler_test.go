@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"rest/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecipeNutrition(t *testing.T) {
+	flour := model.Ingredient{ID: primitive.NewObjectID().Hex(), Name: "flour", Nutrition: &model.Nutrition{Energy: 350, Protein: 10}}
+	salt := model.Ingredient{ID: primitive.NewObjectID().Hex(), Name: "salt"}
+	garlic := model.Ingredient{ID: primitive.NewObjectID().Hex(), Name: "garlic", Nutrition: &model.Nutrition{Energy: 150}}
+	line := func(ingredient model.Ingredient, quantity float64, unit string) model.ResolvedIngredientLine {
+		return model.ResolvedIngredientLine{Ingredient: ingredient, IngredientMeta: model.IngredientMeta{Quantity: quantity, Unit: unit}}
+	}
+	recipe := &model.ResolvedRecipe{
+		Name:     "Bread",
+		Servings: 4,
+		IngredientLines: []model.ResolvedIngredientLine{
+			line(flour, 0.5, "kg"),
+			line(salt, 5, "g"),
+			line(garlic, 2, "clove"),
+			line(flour, 0, ""),
+		},
+	}
+
+	result := recipeNutrition(recipe, nil)
+	if result.Total.Energy != 1750 || result.Total.Protein != 50 {
+		t.Errorf("Total = %+v, want 1750 kcal and 50 g protein", result.Total)
+	}
+	if result.PerServing == nil || result.PerServing.Energy != 437.5 {
+		t.Errorf("PerServing = %+v, want 437.5 kcal", result.PerServing)
+	}
+	reasons := []model.NutritionGapReason{}
+	for _, gap := range result.Missing {
+		reasons = append(reasons, gap.Reason)
+	}
+	want := []model.NutritionGapReason{model.GapNoData, model.GapUnconvertible, model.GapNoQuantity}
+	if len(reasons) != len(want) || reasons[0] != want[0] || reasons[1] != want[1] || reasons[2] != want[2] {
+		t.Errorf("Missing reasons = %v, want %v", reasons, want)
+	}
+	if result.Complete {
+		t.Error("Complete is true with missing lines")
+	}
+
+	complete := &model.ResolvedRecipe{IngredientLines: []model.ResolvedIngredientLine{line(flour, 100, "g")}}
+	if result := recipeNutrition(complete, nil); !result.Complete || result.PerServing != nil {
+		t.Errorf("recipe with only counted lines = %+v, want complete without per serving values", result)
+	}
+
+	deleted := model.IngredientLine{Ingredient: primitive.NewObjectID(), IngredientMeta: model.IngredientMeta{Quantity: 200, Unit: "g"}}
+	complete.Classification.Unresolved = 1
+	result = recipeNutrition(complete, []model.IngredientLine{deleted})
+	if result.Complete {
+		t.Error("Complete is true with a deleted ingredient")
+	}
+	wantGap := model.NutritionGap{IngredientID: deleted.Ingredient.Hex(), Quantity: 200, Unit: "g", Reason: model.GapUnknownIngredient}
+	if len(result.Missing) != 1 || result.Missing[0] != wantGap {
+		t.Errorf("Missing = %+v, want %+v", result.Missing, wantGap)
+	}
+	// The count decides, even if the deleted lines could not be loaded.
+	if result := recipeNutrition(complete, nil); result.Complete {
+		t.Error("Complete is true while the recipe has unresolved lines")
+	}
+}
+
+func TestRecipeNutritionDeletedIngredient(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var flour, sugar model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour", Nutrition: &model.Nutrition{Energy: 350}}, &flour)
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "sugar", Nutrition: &model.Nutrition{Energy: 400}}, &sugar)
+	flourID, _ := primitive.ObjectIDFromHex(flour.ID)
+	sugarID, _ := primitive.ObjectIDFromHex(sugar.ID)
+	var recipe model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:     "Cake",
+		Category: model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{
+			{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 200, Unit: "g"}},
+			{Ingredient: sugarID, IngredientMeta: model.IngredientMeta{Quantity: 100, Unit: "g"}},
+		},
+	}, &recipe)
+	// Delete the ingredient behind the handler's back, as a store that
+	// predates the referential checks could have.
+	if _, err := app.Database.DeleteIngredient(sugar.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var result model.RecipeNutrition
+	request(t, app, "GET", "/recipes/"+recipe.ID+"/nutrition", admin, "", nil, &result)
+	if result.Complete || result.Total.Energy != 700 {
+		t.Errorf("nutrition = %+v, want 700 kcal and incomplete", result)
+	}
+	if len(result.Missing) != 1 || result.Missing[0].IngredientID != sugar.ID || result.Missing[0].Reason != model.GapUnknownIngredient {
+		t.Errorf("Missing = %+v, want the sugar line as an unknown ingredient", result.Missing)
+	}
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"rest/model"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parseListQuery reads the sort, limit and after query parameters shared by
+// the list endpoints.
+func parseListQuery(r *http.Request) (model.ListQuery, error) {
+	query := model.ListQuery{SortField: model.SortByCreated, Limit: defaultPageLimit}
+	params := r.URL.Query()
+
+	switch sort := params.Get("sort"); sort {
+	case "", "created":
+	case "-created":
+		query.Descending = true
+	case "name":
+		query.SortField = model.SortByName
+	case "-name":
+		query.SortField = model.SortByName
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("invalid sort %q, expected name, -name, created or -created", sort)
+	}
+
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		query.Limit = limit
+	}
+
+	if after := params.Get("after"); after != "" {
+		cursor, err := decodeCursor(after)
+		if err != nil {
+			return query, errors.New("invalid after cursor")
+		}
+		query.After = cursor
+	}
+	return query, nil
+}
+
+// encodeCursor turns the position of the last item on a page into the opaque
+// token clients pass back as ?after= to get the next page.
+func encodeCursor(cursor model.ListCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*model.ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var cursor model.ListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("cursor has no ID")
+	}
+	return &cursor, nil
+}
+
+// setNextLink points the Link header at the next page: the current request
+// with its after parameter replaced by the cursor.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	next := *r.URL
+	params := next.Query()
+	params.Set("after", cursor)
+	next.RawQuery = params.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
@@ -0,0 +1,435 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"rest/database"
+	"rest/model"
+	"rest/units"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultExpiringDays = 3
+
+// AllPantryItems godoc
+// @Summary Get the pantry
+// @Description get every item in the caller's pantry, or in every pantry for admins
+// @ID allpantryitems
+// @Produce json
+// @Success 200 {object} []model.PantryItem
+// @Router /pantry [get]
+func (a *App) getAllPantryItems(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	items := a.Database.AllPantryItems()
+	if items == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load pantry"))
+		return
+	}
+	user := currentUser(r)
+	items = slices.DeleteFunc(items, func(item *model.PantryItem) bool {
+		return !canModify(user, item.OwnerID)
+	})
+	data, err := loadDataAsJSON(items)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load pantry"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetPantryItem godoc
+// @Summary Get pantry item by ID
+// @Description get a pantry item by ID
+// @ID getpantryitem
+// @Produce json
+// @Param        id   path      string  true  "Pantry item ID"
+// @Success 200 {object} model.PantryItem
+// @Router /pantry/{id} [get]
+func (a *App) getPantryItemByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	item := a.findPantryItem(w, r)
+	if item == nil {
+		return
+	}
+	data, err := loadDataAsJSON(item)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load pantry item"))
+		return
+	}
+	w.Write(data)
+}
+
+// findPantryItem loads the pantry item in the URL if the caller owns it.
+// Other users' items are reported as missing, like their API keys. It
+// writes an error response and returns nil if there is no such item.
+func (a *App) findPantryItem(w http.ResponseWriter, r *http.Request) *model.PantryItem {
+	item := a.Database.FindPantryItemByID(chi.URLParam(r, "id"))
+	if item == nil || !canModify(currentUser(r), item.OwnerID) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find pantry item"))
+		return nil
+	}
+	return item
+}
+
+// ownPantry returns the items in the user's own pantry.
+func ownPantry(user *model.User, items []*model.PantryItem) []*model.PantryItem {
+	return slices.DeleteFunc(items, func(item *model.PantryItem) bool {
+		return item.OwnerID != user.ID
+	})
+}
+
+// AddPantryItem godoc
+// @Summary Add a pantry item
+// @Description add an amount of an ingredient to the pantry
+// @ID addpantryitem
+// @Produce json
+// Accept json
+// @Param  item   body  model.PantryItemWithoutID  true  "Pantry item"
+// @Success 201 {object} model.PantryItem
+// @Failure 400 {object} ErrorResponse
+// @Router /pantry [post]
+func (a *App) AddPantryItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.PantryItemWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode pantry item"))
+		return
+	}
+	if err := a.validatePantryItem(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = currentUser(r).ID
+	item, err := a.Database.SavePantryItem(&body)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save pantry item"))
+		return
+	}
+	data, err := loadDataAsJSON(item)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load pantry item"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// ReplacePantryItem godoc
+// @Summary Replace a pantry item
+// @Description replace every field of a pantry item
+// @ID replacepantryitem
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Pantry item ID"
+// @Param  item   body  model.PantryItemWithoutID  true  "Pantry item"
+// @Success 200 {object} model.PantryItem
+// @Failure 400 {object} ErrorResponse
+// @Router /pantry/{id} [put]
+func (a *App) ReplacePantryItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.PantryItemWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode pantry item"))
+		return
+	}
+	existing := a.findPantryItem(w, r)
+	if existing == nil {
+		return
+	}
+	if err := a.validatePantryItem(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = existing.OwnerID
+	item, err := a.Database.UpdatePantryItem(existing.ID, &body)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find pantry item"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update pantry item"))
+		return
+	}
+	data, err := loadDataAsJSON(item)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load pantry item"))
+		return
+	}
+	w.Write(data)
+}
+
+func (a *App) validatePantryItem(item *model.PantryItemWithoutID) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+	if a.Database.FindIngredientByID(item.Ingredient.Hex()) == nil {
+		return errors.New("unknown ingredient")
+	}
+	return nil
+}
+
+// DeletePantryItem godoc
+// @Summary Delete a pantry item
+// @Description remove an item from the pantry
+// @ID deletepantryitem
+// @Param        id   path      string  true  "Pantry item ID"
+// @Success 204
+// @Router /pantry/{id} [delete]
+func (a *App) DeletePantryItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	item := a.findPantryItem(w, r)
+	if item == nil {
+		return
+	}
+	deleted, err := a.Database.DeletePantryItem(item.ID)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete pantry item"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find pantry item"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExpiringPantryItems godoc
+// @Summary Find expiring pantry items
+// @Description list the caller's pantry items that expire within the given number of days, or have already expired, with the recipes that would use them up, most expiring ingredients first
+// @ID expiringpantryitems
+// @Produce json
+// @Param        days   query      int  false  "Days from today (default 3)"
+// @Success 200 {object} model.ExpiringPantryResponse
+// @Router /pantry/expiring [get]
+func (a *App) ExpiringPantryItems(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	days := defaultExpiringDays
+	if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+		parsed, err := strconv.Atoi(daysParam)
+		if err != nil || parsed < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("days must be a non-negative integer"))
+			return
+		}
+		days = parsed
+	}
+	items := a.Database.AllPantryItems()
+	ingredients := a.Database.AllIngredients()
+	recipes := a.Database.AllRecipes()
+	if items == nil || ingredients == nil || recipes == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load pantry"))
+		return
+	}
+	items = ownPantry(currentUser(r), items)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	byID := map[string]*model.Ingredient{}
+	for _, ingredient := range ingredients {
+		byID[ingredient.ID] = ingredient
+	}
+	response := model.ExpiringPantryResponse{Items: []model.ExpiringPantryItem{}, Recipes: []model.ExpiringRecipe{}}
+	expiring := map[string]bool{}
+	for _, item := range items {
+		if item.ExpiryDate == "" {
+			continue
+		}
+		expiry, err := time.Parse(time.DateOnly, item.ExpiryDate)
+		if err != nil || expiry.After(today.AddDate(0, 0, days)) {
+			continue
+		}
+		expiringItem := model.ExpiringPantryItem{PantryItem: *item, DaysLeft: int(expiry.Sub(today).Hours() / 24)}
+		if ingredient := byID[item.Ingredient.Hex()]; ingredient != nil {
+			expiringItem.IngredientName = ingredient.Name
+		}
+		response.Items = append(response.Items, expiringItem)
+		expiring[item.Ingredient.Hex()] = true
+	}
+	sort.SliceStable(response.Items, func(i, j int) bool {
+		return response.Items[i].ExpiryDate < response.Items[j].ExpiryDate
+	})
+
+	for _, recipe := range recipes {
+		uses := []model.Ingredient{}
+		for _, line := range recipe.IngredientLines {
+			ingredient := byID[line.Ingredient.Hex()]
+			if expiring[line.Ingredient.Hex()] && ingredient != nil && !slices.ContainsFunc(uses, func(used model.Ingredient) bool { return used.ID == ingredient.ID }) {
+				uses = append(uses, *ingredient)
+			}
+		}
+		if len(uses) > 0 {
+			response.Recipes = append(response.Recipes, model.ExpiringRecipe{
+				Recipe: model.RecipeSummary{ID: recipe.ID, Name: recipe.Name},
+				Uses:   uses,
+			})
+		}
+	}
+	sort.SliceStable(response.Recipes, func(i, j int) bool {
+		if len(response.Recipes[i].Uses) != len(response.Recipes[j].Uses) {
+			return len(response.Recipes[i].Uses) > len(response.Recipes[j].Uses)
+		}
+		return response.Recipes[i].Recipe.Name < response.Recipes[j].Recipe.Name
+	})
+
+	data, err := loadDataAsJSON(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load pantry"))
+		return
+	}
+	w.Write(data)
+}
+
+// RecipeCooked godoc
+// @Summary Mark a recipe as cooked
+// @Description take the recipe's ingredients out of the caller's pantry, using the items that expire first. Ingredients the pantry does not have enough of, or has in units that cannot be converted, are listed as missing.
+// @ID recipecooked
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        servings   query      int  false  "Servings cooked, if not what the recipe makes"
+// @Success 200 {object} model.CookedResponse
+// @Router /recipes/{id}/cooked [post]
+func (a *App) RecipeCooked(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	recipe := a.Database.FindRecipeByID(chi.URLParam(r, "id"))
+	if recipe == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	factor := 1.0
+	if servingsParam := r.URL.Query().Get("servings"); servingsParam != "" {
+		servings, err := strconv.Atoi(servingsParam)
+		if err != nil || servings <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("servings must be a positive integer"))
+			return
+		}
+		if recipe.Servings == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Recipe does not say how many servings it makes"))
+			return
+		}
+		factor = float64(servings) / float64(recipe.Servings)
+	}
+	items := a.Database.AllPantryItems()
+	if items == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load pantry"))
+		return
+	}
+
+	response, taken := takeFromPantry(recipe, factor, ownPantry(currentUser(r), items))
+	if err := a.Database.TakeFromPantry(taken); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update pantry"))
+		return
+	}
+	data, err := loadDataAsJSON(response)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load pantry"))
+		return
+	}
+	w.Write(data)
+}
+
+// takeFromPantry works out what cooking the recipe, scaled by factor, takes
+// from the pantry items. It returns the amounts it takes from each item,
+// keyed by item ID.
+func takeFromPantry(recipe *model.ResolvedRecipe, factor float64, items []*model.PantryItem) (model.CookedResponse, map[string]float64) {
+	// Use up the items that expire first. Items without an expiry date go
+	// last.
+	sort.SliceStable(items, func(i, j int) bool {
+		ei, ej := items[i].ExpiryDate, items[j].ExpiryDate
+		if ei == "" || ej == "" {
+			return ej == "" && ei != ""
+		}
+		return ei < ej
+	})
+	byIngredient := map[string][]*model.PantryItem{}
+	for _, item := range items {
+		byIngredient[item.Ingredient.Hex()] = append(byIngredient[item.Ingredient.Hex()], item)
+	}
+
+	response := model.CookedResponse{Used: []model.PantryUsage{}, Missing: []model.MissingIngredient{}}
+	quantities := map[string]float64{}
+	taken := map[string]float64{}
+	for _, line := range recipe.IngredientLines {
+		ingredient := line.Ingredient
+		needed := units.Quantity{Amount: line.Quantity * factor, Unit: line.Unit}
+		for _, item := range byIngredient[ingredient.ID] {
+			if needed.Amount <= 0 {
+				break
+			}
+			left, ok := quantities[item.ID]
+			if !ok {
+				left = item.Quantity
+			}
+			if left <= 0 {
+				continue
+			}
+			converted, err := units.Convert(needed, item.Unit, ingredient.Density)
+			if err != nil || converted.Amount <= 0 {
+				continue
+			}
+			amount := math.Min(converted.Amount, left)
+			left -= amount
+			// Leftovers from floating point rounding count as used up.
+			if left < 1e-9 {
+				left = 0
+			}
+			quantities[item.ID] = left
+			taken[item.ID] += amount
+			needed.Amount *= 1 - amount/converted.Amount
+			response.Used = append(response.Used, model.PantryUsage{
+				ItemID:     item.ID,
+				Ingredient: ingredient,
+				Quantity:   amount,
+				Unit:       item.Unit,
+				Remaining:  left,
+			})
+		}
+		if needed.Amount > 1e-9 {
+			missing := units.Simplify(needed)
+			response.Missing = append(response.Missing, model.MissingIngredient{
+				Ingredient: ingredient,
+				Quantity:   missing.Amount,
+				Unit:       missing.Unit,
+			})
+		}
+	}
+	return response, taken
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"rest/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecipeCooked(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var flour model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour"}, &flour)
+	flourID, _ := primitive.ObjectIDFromHex(flour.ID)
+	var recipe model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:            "Bread",
+		Category:        model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 500, Unit: "g"}}},
+	}, &recipe)
+	var soon, later model.PantryItem
+	request(t, app, "POST", "/pantry", admin, "", model.PantryItemWithoutID{Ingredient: flourID, Quantity: 300, Unit: "g", ExpiryDate: "2026-01-01"}, &soon)
+	request(t, app, "POST", "/pantry", admin, "", model.PantryItemWithoutID{Ingredient: flourID, Quantity: 1, Unit: "kg", ExpiryDate: "2026-06-01"}, &later)
+	// Half of the second bag was used for something else.
+	if err := app.Database.TakeFromPantry(map[string]float64{later.ID: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cooked model.CookedResponse
+	request(t, app, "POST", "/recipes/"+recipe.ID+"/cooked", admin, "", nil, &cooked)
+	if len(cooked.Used) != 2 || len(cooked.Missing) != 0 {
+		t.Fatalf("cooked = %+v, want both items used", cooked)
+	}
+	if item := app.Database.FindPantryItemByID(soon.ID); item != nil {
+		t.Errorf("item expiring first = %+v, want it used up", item)
+	}
+	if item := app.Database.FindPantryItemByID(later.ID); item == nil || item.Quantity != 0.3 {
+		t.Errorf("item expiring later = %+v, want 0.3 kg left", item)
+	}
+
+	// Items deleted in the meantime are skipped rather than failing.
+	if err := app.Database.TakeFromPantry(map[string]float64{soon.ID: 1, later.ID: 1}); err != nil {
+		t.Errorf("taking from deleted items: %v", err)
+	}
+	if item := app.Database.FindPantryItemByID(later.ID); item != nil {
+		t.Errorf("item = %+v, want it used up", item)
+	}
+}
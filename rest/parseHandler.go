@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"rest/model"
+	"rest/recipetext"
+	"rest/search"
+	"strings"
+)
+
+// ParseIngredients godoc
+// @Summary Read ingredient lines from text
+// @Description read lines like "2 1/2 cups all-purpose flour, sifted" as quantity, unit, ingredient and preparation note, and match each to an ingredient by name. Lines ending in a colon are group headings. With create_missing, ingredients that do not exist yet are added, which needs the ingredients:write scope.
+// @ID parseingredients
+// @Produce json
+// Accept json
+// @Param  request   body  model.ParseIngredientsRequest  true  "Lines of text"
+// @Success 200 {object} []model.ParsedIngredientLine
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /ingredients/parse [post]
+func (a *App) ParseIngredients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.ParseIngredientsRequest
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode lines"))
+		return
+	}
+	if body.CreateMissing && !checkScope(w, r, model.ScopeIngredientsWrite) {
+		return
+	}
+	parsed, err := a.parseIngredientText(r, body.Lines, body.CreateMissing)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to match ingredients"))
+		return
+	}
+	data, err := loadDataAsJSON(parsed)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load ingredient lines"))
+		return
+	}
+	w.Write(data)
+}
+
+// parseIngredientText reads lines of text as ingredient lines and matches
+// them to ingredients, adding missing ones if createMissing is set. Lines
+// that cannot be read or matched are returned with an error; err is only
+// set when the ingredients cannot be loaded or added.
+func (a *App) parseIngredientText(r *http.Request, texts []string, createMissing bool) ([]model.ParsedIngredientLine, error) {
+	ingredients := a.Database.AllIngredients()
+	if ingredients == nil {
+		return nil, errors.New("failed to load ingredients")
+	}
+	parsed := []model.ParsedIngredientLine{}
+	group := ""
+	for _, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if heading, ok := recipetext.Heading(text); ok {
+			group = heading
+			continue
+		}
+		line := model.ParsedIngredientLine{Text: text, Group: group}
+		read, err := recipetext.Parse(text)
+		if err != nil {
+			line.Error = err.Error()
+			parsed = append(parsed, line)
+			continue
+		}
+		line.Name = read.Name
+		line.IngredientMeta = model.IngredientMeta{Quantity: read.Quantity, Unit: read.Unit}
+		line.Note = read.Note
+		line.Optional = read.Optional
+		line.Ingredient, line.Match = a.matchIngredientName(read.Name, ingredients)
+		if line.Ingredient == nil && createMissing {
+			created := a.Database.SaveIngredient(&model.IngredientWithoutID{Name: read.Name, OwnerID: currentUser(r).ID})
+			if created == nil {
+				return nil, errors.New("failed to add ingredient " + read.Name)
+			}
+			ingredients = append(ingredients, created)
+			line.Ingredient, line.Match = created, model.MatchCreated
+		}
+		if line.Ingredient == nil {
+			line.Error = "no ingredient matches " + read.Name
+		}
+		parsed = append(parsed, line)
+	}
+	return parsed, nil
+}
+
+// matchIngredientName finds the ingredient a name from an ingredient line
+// refers to: the one with that name or alias, or else the most specific
+// one whose name or alias words all appear in it, ignoring case and
+// plurals, so "all-purpose flour" matches flour.
+func (a *App) matchIngredientName(name string, ingredients []*model.Ingredient) (*model.Ingredient, model.IngredientMatch) {
+	if ingredient := a.Database.FindIngredientByName(name); ingredient != nil {
+		return ingredient, model.MatchExact
+	}
+	terms := map[string]bool{}
+	for _, term := range search.Terms(name) {
+		terms[term] = true
+	}
+	var best *model.Ingredient
+	bestTerms := 0
+	for _, ingredient := range ingredients {
+		for _, own := range ingredient.Names() {
+			ingredientTerms := search.Terms(own)
+			if len(ingredientTerms) == 0 || len(ingredientTerms) < bestTerms {
+				continue
+			}
+			matches := true
+			for _, term := range ingredientTerms {
+				if !terms[term] {
+					matches = false
+					break
+				}
+			}
+			if !matches {
+				continue
+			}
+			if len(ingredientTerms) > bestTerms || len(ingredient.Name) < len(best.Name) {
+				best, bestTerms = ingredient, len(ingredientTerms)
+			}
+		}
+	}
+	if best == nil {
+		return nil, ""
+	}
+	return best, model.MatchFuzzy
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"rest/model"
+	"slices"
+)
+
+// anonymous is the role of callers that have not logged in.
+const anonymous model.Role = ""
+
+// publicScopes are granted to callers that have not logged in. Shopping
+// lists, meal plans and the pantry belong to users, so they are not public.
+var publicScopes = []model.Scope{model.ScopeRecipesRead, model.ScopeIngredientsRead}
+
+var readScopes = append(slices.Clone(publicScopes), model.ScopePlannerRead)
+
+var editorScopes = append(slices.Clone(readScopes), model.ScopeRecipesWrite, model.ScopeIngredientsWrite, model.ScopePlannerWrite)
+
+// policy lists the scopes each role is granted. Routes declare the scope
+// they need with authorize. Which recipes and ingredients an editor may
+// change is further limited to their own, see canModify.
+var policy = map[model.Role][]model.Scope{
+	anonymous:        publicScopes,
+	model.RoleViewer: readScopes,
+	model.RoleEditor: editorScopes,
+	model.RoleAdmin:  model.AllScopes,
+	// Accounts created before roles existed had the role "user", which
+	// could do what editors can.
+	"user": editorScopes,
+}
+
+// roleAllows reports whether role is granted scope.
+func roleAllows(role model.Role, scope model.Scope) bool {
+	return slices.Contains(policy[role], scope)
+}
+
+// authorize only lets callers through whose role grants scope. Requests made
+// with an API key also need the key to have the scope, so a key can never
+// do more than its owner.
+func authorize(scope model.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if checkScope(w, r, scope) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// checkScope is authorize for handlers that only need a scope for some
+// requests. It writes an error response and returns false if the caller
+// lacks scope.
+func checkScope(w http.ResponseWriter, r *http.Request, scope model.Scope) bool {
+	user := currentUser(r)
+	role := anonymous
+	if user != nil {
+		role = user.Role
+	}
+	if !roleAllows(role, scope) {
+		if user == nil {
+			unauthorized(w, "Log in to make changes")
+			return false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse(fmt.Sprintf("The %s role does not have the %s scope", role, scope)))
+		return false
+	}
+	if key := currentAPIKey(r); key != nil && !key.HasScope(scope) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse(fmt.Sprintf("The API key is missing the %s scope", scope)))
+		return false
+	}
+	return true
+}
+
+// developmentOnly disables handlers outside the development profile, like
+// the ones that seed the database with test data.
+func (a *App) developmentOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.Config.IsDevelopment() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(getErrorResponse("Only available in the development profile"))
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"testing"
+)
+
+func TestPlannerOwnership(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	owner, _ := signUpEditor(t, app, admin, "bob")
+	other, _ := signUpEditor(t, app, admin, "carol")
+
+	var ingredient model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour"}, &ingredient)
+	var recipe model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{Name: "Bread", Category: model.CategoryMainCourse}, &recipe)
+
+	var list model.ShoppingList
+	request(t, app, "POST", "/shopping-lists", owner, "", model.ShoppingListRequest{Recipes: []model.ShoppingListRecipe{{RecipeID: recipe.ID}}}, &list)
+	var plan model.MealPlan
+	request(t, app, "POST", "/meal-plans", owner, "", model.MealPlanWithoutID{Name: "Week", Entries: []model.MealPlanEntry{{Date: "2026-01-05", Slot: model.Dinner, RecipeID: recipe.ID}}}, &plan)
+	var item model.PantryItem
+	request(t, app, "POST", "/pantry", owner, "", map[string]any{"ingredient": ingredient.ID, "quantity": 500, "unit": "g"}, &item)
+	if list.ID == "" || plan.ID == "" || item.ID == "" {
+		t.Fatalf("creating planner data: list %+v, plan %+v, item %+v", list, plan, item)
+	}
+
+	for _, collection := range []struct {
+		path string
+		ID   string
+	}{
+		{"/shopping-lists", list.ID},
+		{"/meal-plans", plan.ID},
+		{"/pantry", item.ID},
+	} {
+		for _, test := range []struct {
+			name  string
+			token string
+			count int
+		}{
+			{"owner", owner, 1},
+			{"other user", other, 0},
+			{"admin", admin, 1},
+		} {
+			var all []map[string]any
+			request(t, app, "GET", collection.path, test.token, "", nil, &all)
+			if len(all) != test.count {
+				t.Errorf("%s listing %s got %d, want %d", test.name, collection.path, len(all), test.count)
+			}
+		}
+		path := collection.path + "/" + collection.ID
+		if w := request(t, app, "GET", path, owner, "", nil, nil); w.Code != http.StatusOK {
+			t.Errorf("owner getting %s = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		if w := request(t, app, "GET", path, other, "", nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("other user getting %s = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+		if w := request(t, app, "DELETE", path, other, "", nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("other user deleting %s = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+
+	if w := request(t, app, "PUT", "/meal-plans/"+plan.ID, other, "", model.MealPlanWithoutID{Name: "Mine now"}, nil); w.Code != http.StatusNotFound {
+		t.Errorf("other user replacing the meal plan = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := request(t, app, "PATCH", "/shopping-lists/"+list.ID+"/items/1", other, "", model.ShoppingListItemUpdate{Checked: true}, nil); w.Code != http.StatusNotFound {
+		t.Errorf("other user checking a list item = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	var replaced model.MealPlan
+	request(t, app, "PUT", "/meal-plans/"+plan.ID, owner, "", model.MealPlanWithoutID{Name: "Next week", OwnerID: "someone"}, &replaced)
+	if replaced.OwnerID != plan.OwnerID || replaced.OwnerID == "" {
+		t.Errorf("replacing the meal plan changed its owner from %q to %q", plan.OwnerID, replaced.OwnerID)
+	}
+}
@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"rest/model"
+	"rest/units"
+)
+
+// scaleRecipe rewrites the ingredient quantities of recipe for the given
+// number of servings. The recipe must say how many servings it makes.
+func scaleRecipe(recipe *model.ResolvedRecipe, servings int) {
+	factor := float64(servings) / float64(recipe.Servings)
+	for i, line := range recipe.IngredientLines {
+		scaled := units.Scale(units.Quantity{Amount: line.Quantity, Unit: line.Unit}, factor)
+		recipe.IngredientLines[i].IngredientMeta = model.IngredientMeta{Quantity: scaled.Amount, Unit: scaled.Unit}
+	}
+	recipe.Servings = servings
+}
+
+// parseUnitSystem reads the units query parameter. ok is false when the
+// caller did not ask for a measuring system.
+func parseUnitSystem(r *http.Request) (system units.System, ok bool, err error) {
+	param := r.URL.Query().Get("units")
+	if param == "" {
+		return "", false, nil
+	}
+	system, ok = units.ParseSystem(param)
+	if !ok {
+		return "", false, errors.New("units must be metric or imperial")
+	}
+	return system, true, nil
+}
+
+// convertQuantity rewrites a quantity in place in the given measuring
+// system.
+func convertQuantity(meta *model.IngredientMeta, system units.System) {
+	converted := units.ToSystem(units.Quantity{Amount: meta.Quantity, Unit: meta.Unit}, system)
+	*meta = model.IngredientMeta{Quantity: converted.Amount, Unit: converted.Unit}
+}
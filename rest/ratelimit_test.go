@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Unix(0, 0)
+	for i := range 60 {
+		if ok, _ := limiter.allow("key", 60, now); !ok {
+			t.Fatalf("request %d of a burst of 60 was denied", i+1)
+		}
+	}
+	ok, wait := limiter.allow("key", 60, now)
+	if ok || wait != time.Second {
+		t.Errorf("request 61 = %v, %v, want false, %v", ok, wait, time.Second)
+	}
+	ok, wait = limiter.allow("key", 60, now.Add(500*time.Millisecond))
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("request after half a token = %v, %v, want false, %v", ok, wait, 500*time.Millisecond)
+	}
+	if ok, _ := limiter.allow("key", 60, now.Add(time.Second)); !ok {
+		t.Error("request after a token refilled was denied")
+	}
+	if ok, _ := limiter.allow("key", 60, now.Add(time.Second)); ok {
+		t.Error("second request after one token refilled was allowed")
+	}
+}
+
+func TestRateLimiterCapacity(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Unix(0, 0)
+	limiter.allow("key", 10, now)
+	// An idle hour refills the bucket to its capacity, not beyond.
+	later := now.Add(time.Hour)
+	allowed := 0
+	for range 20 {
+		if ok, _ := limiter.allow("key", 10, later); ok {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Errorf("allowed %d requests after an idle hour, want 10", allowed)
+	}
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Unix(0, 0)
+	if ok, _ := limiter.allow("a", 1, now); !ok {
+		t.Fatal("first request for a was denied")
+	}
+	if ok, _ := limiter.allow("a", 1, now); ok {
+		t.Error("second request for a was allowed")
+	}
+	if ok, _ := limiter.allow("b", 1, now); !ok {
+		t.Error("a's requests counted against b")
+	}
+}
@@ -5,16 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"rest/database"
 	"rest/model"
+	"rest/search"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var db = database.Connect()
-
 func loadDataAsJSON[T any](dataToConvert T) ([]byte, error) {
 	data, err := json.Marshal(dataToConvert)
 	if err != nil {
@@ -37,31 +41,94 @@ func getErrorResponse(errorText string) []byte {
 
 // AllRecipes godoc
 // @Summary Get all recipes
-// @Description get all recipes
+// @Description get one page of recipes. Follow next_cursor, or the Link header, for the next page.
 // @ID allrecipes
 // @Produce json
-// @Success 200 {object} []model.Recipe
+// @Param        category   query      string  false  "Only return recipes in this category"
+// @Param        ingredient   query      string  false  "Only return recipes that use this ingredient, by ID or name"
+// @Param        exclude_allergens   query      string  false  "Leave out recipes with any of these allergens, comma separated, or with ingredients whose allergens are not known"
+// @Param        diet   query      string  false  "Only return recipes whose ingredients all suit this diet: vegan, vegetarian or pescatarian"
+// @Param        sort   query      string  false  "name, -name, created (default) or -created"
+// @Param        limit   query      int  false  "Page size (default 50, max 200)"
+// @Param        after   query      string  false  "Cursor from the previous page"
+// @Param        units   query      string  false  "Convert quantities to metric or imperial units"
+// @Success 200 {object} model.RecipePage
+// @Failure 400 {object} ErrorResponse
 // @Router /recipes [get]
-func getAllRecipes(w http.ResponseWriter, r *http.Request) {
+func (a *App) getAllRecipes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var recipes = db.AllRecipes()
-	if recipes == nil {
-		fmt.Errorf("Failed to load recipe as json")
+	query, err := parseListQuery(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	query.Category = model.Category(r.URL.Query().Get("category"))
+	if query.Category != "" && !query.Category.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Invalid category"))
+		return
+	}
+	if ingredientParam := r.URL.Query().Get("ingredient"); ingredientParam != "" {
+		ingredient := a.Database.FindIngredientByID(ingredientParam)
+		if ingredient == nil {
+			ingredient = a.Database.FindIngredientByName(ingredientParam)
+		}
+		if ingredient == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Unknown ingredient"))
+			return
+		}
+		ingredientID, _ := primitive.ObjectIDFromHex(ingredient.ID)
+		query.Ingredient = &ingredientID
+	}
+	query.AllowedIngredients, err = a.parseDietaryQuery(r)
+	if errors.Is(err, errIngredientsUnavailable) {
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(getErrorResponse("Failed to load recipe"))
+		w.Write(getErrorResponse("Failed to load ingredients"))
 		return
 	}
-	if len(recipes) == 0 {
-		emptyList := make([]model.Recipe, 0)
-		emptyData, _ := loadDataAsJSON(emptyList)
-		w.Write(emptyData)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
 		return
 	}
-	data, err := loadDataAsJSON(recipes)
+	system, convert, err := parseUnitSystem(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+
+	recipes, more, err := a.Database.ListRecipes(query)
+	if err != nil {
+		log.Print("Failed to load recipes: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load recipe"))
+		return
+	}
+	if recipes == nil {
+		recipes = []*model.Recipe{}
+	}
+	if convert {
+		for _, recipe := range recipes {
+			for i := range recipe.IngredientLines {
+				convertQuantity(&recipe.IngredientLines[i].IngredientMeta, system)
+			}
+		}
+	}
+	page := model.RecipePage{Data: recipes}
+	if more {
+		last := recipes[len(recipes)-1]
+		page.NextCursor = encodeCursor(model.ListCursor{Name: last.Name, ID: last.ID})
+		setNextLink(w, r, page.NextCursor)
+	}
+	data, err := loadDataAsJSON(page)
 	if err != nil {
-		fmt.Errorf("Failed to load recipe as json")
+		log.Print("Failed to load recipe as json: ", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Could not load recipe"))
+		return
 	}
 	w.Write(data)
 }
@@ -71,39 +138,266 @@ func getAllRecipes(w http.ResponseWriter, r *http.Request) {
 // @Description get a recipe by ID
 // @ID getrecipe
 // @Produce json
-// @Success 200 {object} model.Recipe
+// @Success 200 {object} model.ResolvedRecipe
+// @Failure 400 {object} ErrorResponse
 // @Param        id   path      string  true  "Recipe ID"
+// @Param        If-None-Match   header      string  false  "ETag of a copy of the recipe the caller has"
+// @Param        servings   query      int  false  "Scale ingredient quantities to this many servings"
+// @Param        units   query      string  false  "Convert quantities to metric or imperial units"
+// @Success 304
 // @Router /recipes/{id} [get]
-func getRecipeByID(w http.ResponseWriter, r *http.Request) {
+func (a *App) getRecipeByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	idParam := chi.URLParam(r, "id")
-	var recipes = db.FindRecipeByID(idParam)
+	var recipes = a.Database.FindRecipeByID(idParam)
 	if recipes == nil {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(getErrorResponse("Could not find recipe"))
 		return
 	}
+	servings := 0
+	if servingsParam := r.URL.Query().Get("servings"); servingsParam != "" {
+		var err error
+		servings, err = strconv.Atoi(servingsParam)
+		if err != nil || servings <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("servings must be a positive integer"))
+			return
+		}
+		if recipes.Servings == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Recipe does not say how many servings it makes"))
+			return
+		}
+	}
+	system, convert, err := parseUnitSystem(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	etag := recipeVariantETag(recipes.Version, servings, system)
+	if etagMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if servings > 0 {
+		scaleRecipe(recipes, servings)
+	}
+	if convert {
+		for i := range recipes.IngredientLines {
+			convertQuantity(&recipes.IngredientLines[i].IngredientMeta, system)
+		}
+	}
 	data, err := loadDataAsJSON(recipes)
 	if err != nil {
-		fmt.Errorf("Failed to load recipe as json")
+		log.Print("Failed to load recipe as json: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Failed to load recipe"))
 		return
 	}
+	w.Header().Set("ETag", etag)
+	w.Write(data)
+
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchRecipes godoc
+// @Summary Search recipes
+// @Description full-text search across recipe names, descriptions, steps and ingredient names, ranked by relevance with highlighted snippets
+// @ID searchrecipes
+// @Produce json
+// @Param        q   query      string  true  "Search text"
+// @Param        category   query      string  false  "Only return recipes in this category"
+// @Param        limit   query      int  false  "Maximum number of results (default 20, max 100)"
+// @Param        units   query      string  false  "Convert quantities to metric or imperial units"
+// @Success 200 {object} []model.RecipeSearchHit
+// @Router /recipes/search [get]
+func (a *App) searchRecipes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("q is required"))
+		return
+	}
+	category := model.Category(r.URL.Query().Get("category"))
+	if category != "" && !category.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Invalid category"))
+		return
+	}
+	limit := defaultSearchLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("limit must be between 1 and 100"))
+			return
+		}
+		limit = parsed
+	}
+	system, convert, err := parseUnitSystem(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+
+	hits := a.Database.SearchRecipes(query, category, limit)
+	if hits == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to search recipes"))
+		return
+	}
+	for _, hit := range hits {
+		hit.Highlights = highlightRecipe(&hit.Recipe, query)
+		if convert {
+			for i := range hit.Recipe.IngredientLines {
+				convertQuantity(&hit.Recipe.IngredientLines[i].IngredientMeta, system)
+			}
+		}
+	}
+	data, err := loadDataAsJSON(hits)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load recipe"))
+		return
+	}
 	w.Write(data)
+}
 
+func highlightRecipe(recipe *model.ResolvedRecipe, query string) []model.SearchHighlight {
+	highlights := []model.SearchHighlight{}
+	addHighlight := func(field string, text string) {
+		if snippet, ok := search.Highlight(text, query); ok {
+			highlights = append(highlights, model.SearchHighlight{Field: field, Snippet: snippet})
+		}
+	}
+	addHighlight("name", recipe.Name)
+	addHighlight("description", recipe.Description)
+	for _, step := range recipe.Steps {
+		addHighlight("steps", step)
+	}
+	for _, line := range recipe.IngredientLines {
+		addHighlight("ingredients", line.Ingredient.Name)
+	}
+	return highlights
 }
 
 // AddRecipe godoc
 // @Summary Add a recipe
-// @Description get a recipe by ID
+// @Description add a recipe. Instead of ingredient_lines, the ingredients can be sent as lines of text in ingredients_text, which are read like POST /ingredients/parse does; set create_missing_ingredients to add ingredients that do not exist yet.
 // @ID addrecipe
 // @Produce json
 // Accept json
 // @Param  recipe   body  model.Recipe  true  "Recipe"
-// @Success 201 {object} model.RecipeWithoutID
+// @Param        message   query      string  false  "Change message for the revision history"
+// @Success 201 {object} model.Recipe
+// @Header 201 {string} Location "URL of the new recipe"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /recipes [post]
-func AddRecipe(w http.ResponseWriter, r *http.Request) {
+func (a *App) AddRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.RecipeWithoutID
+	var text model.IngredientsText
+
+	content, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(content, &body)
+	}
+	if err == nil {
+		err = json.Unmarshal(content, &text)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode recipe"))
+		return
+	}
+	if len(text.IngredientsText) > 0 {
+		if len(body.IngredientLines) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Send either ingredient_lines or ingredients_text"))
+			return
+		}
+		if text.CreateMissingIngredients && !checkScope(w, r, model.ScopeIngredientsWrite) {
+			return
+		}
+		parsed, err := a.parseIngredientText(r, text.IngredientsText, text.CreateMissingIngredients)
+		if err != nil {
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(getErrorResponse("Failed to match ingredients"))
+			return
+		}
+		var problems []string
+		for _, line := range parsed {
+			ingredientLine, err := line.Line()
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			body.IngredientLines = append(body.IngredientLines, ingredientLine)
+		}
+		if len(problems) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Could not read ingredients: " + strings.Join(problems, "; ")))
+			return
+		}
+	}
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = currentUser(r).ID
+	recipe := a.Database.SaveRecipe(&body)
+	if recipe == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save recipe"))
+		return
+	}
+	message := r.URL.Query().Get("message")
+	if message == "" {
+		message = "Created"
+	}
+	a.recordRevision(r, recipe.ID, nil, &body, message)
+	data, err := loadDataAsJSON(recipe)
+	if err != nil {
+		log.Print("Failed to load recipe as json: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load recipe"))
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe.Version))
+	w.Header().Set("Location", "/recipes/"+recipe.ID)
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// ReplaceRecipe godoc
+// @Summary Replace a recipe
+// @Description replace every field of a recipe
+// @ID replacerecipe
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        If-Match   header      string  true  "ETag of the recipe being changed"
+// @Param  recipe   body  model.RecipeWithoutID  true  "Recipe"
+// @Param        message   query      string  false  "Change message for the revision history"
+// @Success 200 {object} model.ResolvedRecipe
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /recipes/{id} [put]
+func (a *App) ReplaceRecipe(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
 	var body model.RecipeWithoutID
 
 	res := json.NewDecoder(r.Body).Decode(&body)
@@ -112,25 +406,311 @@ func AddRecipe(w http.ResponseWriter, r *http.Request) {
 		w.Write(getErrorResponse("Failed to decode recipe"))
 		return
 	}
-	db.SaveRecipe(&body)
-	data, err := loadDataAsJSON(body)
+	recipe := a.recipeForChange(w, r, idParam)
+	if recipe == nil {
+		return
+	}
+	body.OwnerID = recipe.OwnerID
+	a.writeUpdatedRecipe(w, r, recipe, &body, r.URL.Query().Get("message"))
+}
+
+// PatchRecipe godoc
+// @Summary Update a recipe
+// @Description update some fields of a recipe using JSON Merge Patch (RFC 7386)
+// @ID patchrecipe
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        If-Match   header      string  true  "ETag of the recipe being changed"
+// @Param  patch   body  model.RecipeWithoutID  true  "Merge patch"
+// @Param        message   query      string  false  "Change message for the revision history"
+// @Success 200 {object} model.ResolvedRecipe
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /recipes/{id} [patch]
+func (a *App) PatchRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	recipe := a.recipeForChange(w, r, idParam)
+	if recipe == nil {
+		return
+	}
+	original, err := loadDataAsJSON(recipe.WithoutID())
 	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(getErrorResponse("Failed to load recipe"))
-		fmt.Errorf("Failed to load recipe as json")
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to read patch"))
+		return
+	}
+	patch, legacy := legacyIngredientsPatch(patch)
+	patched, err := applyMergePatch(original, patch)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode patch"))
+		return
+	}
+	var body model.RecipeWithoutID
+	if err := json.Unmarshal(patched, &body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Patch does not produce a valid recipe"))
+		return
+	}
+	if legacy {
+		keepLineDetails(body.IngredientLines, recipe.IngredientLines)
+	}
+	body.OwnerID = recipe.OwnerID
+	a.writeUpdatedRecipe(w, r, recipe, &body, r.URL.Query().Get("message"))
+}
+
+// legacyIngredientsPatch makes a patch of the ingredients or
+// ingredients_meta arrays replace the ingredient lines, which would
+// otherwise take precedence over them. legacy reports whether it is such a
+// patch.
+func legacyIngredientsPatch(patch []byte) (rewritten []byte, legacy bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return patch, false
+	}
+	_, lines := fields["ingredient_lines"]
+	_, ingredients := fields["ingredients"]
+	_, metas := fields["ingredients_meta"]
+	if lines || !(ingredients || metas) {
+		return patch, false
+	}
+	fields["ingredient_lines"] = json.RawMessage("null")
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return patch, false
+	}
+	return rewritten, true
+}
+
+// keepLineDetails copies the note, optional flag and group of each line in
+// before to the line at the same position in lines if it is for the same
+// ingredient. The legacy arrays cannot express them, so a patch of those
+// arrays would otherwise drop them.
+func keepLineDetails(lines []model.IngredientLine, before []model.IngredientLine) {
+	for i := range min(len(lines), len(before)) {
+		if lines[i].Ingredient == before[i].Ingredient {
+			lines[i].Note = before[i].Note
+			lines[i].Optional = before[i].Optional
+			lines[i].Group = before[i].Group
+		}
+	}
+}
+
+// recipeForChange loads a recipe the caller wants to change. It writes an
+// error response and returns nil if there is no such recipe, the caller may
+// not change it, or the If-Match header does not name its current version.
+func (a *App) recipeForChange(w http.ResponseWriter, r *http.Request, ID string) *model.Recipe {
+	recipe := a.Database.FindRecipeDocumentByID(ID)
+	if recipe == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return nil
+	}
+	if !canModify(currentUser(r), recipe.OwnerID) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse("Only the owner of the recipe or an admin can change it"))
+		return nil
+	}
+	if !checkIfMatch(w, r, recipe) {
+		return nil
+	}
+	return recipe
+}
+
+// unresolvedLines returns the lines of a recipe whose ingredient no longer
+// exists. FindRecipeByID leaves them out and only counts them in the
+// recipe's classification.
+func (a *App) unresolvedLines(recipe *model.ResolvedRecipe) []model.IngredientLine {
+	if recipe.Classification.Unresolved == 0 {
+		return nil
+	}
+	document := a.Database.FindRecipeDocumentByID(recipe.ID)
+	if document == nil {
+		return nil
+	}
+	resolved := map[string]bool{}
+	for _, line := range recipe.IngredientLines {
+		resolved[line.Ingredient.ID] = true
+	}
+	var unresolved []model.IngredientLine
+	for _, line := range document.IngredientLines {
+		if !resolved[line.Ingredient.Hex()] {
+			unresolved = append(unresolved, line)
+		}
+	}
+	return unresolved
+}
+
+// writeUpdatedRecipe replaces the recipe with body, records the change as a
+// revision and responds with the updated recipe.
+func (a *App) writeUpdatedRecipe(w http.ResponseWriter, r *http.Request, before *model.Recipe, body *model.RecipeWithoutID, message string) {
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	recipe, err := a.Database.UpdateRecipe(before.ID, before.Version, body)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	if errors.Is(err, database.ErrVersionMismatch) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write(getErrorResponse("The recipe has been changed since you loaded it"))
+		return
+	}
+	if err != nil || recipe == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update recipe"))
+		return
+	}
+	a.recordRevision(r, before.ID, before, body, message)
+	data, err := loadDataAsJSON(recipe)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load recipe"))
+		return
+	}
+	w.Header().Set("ETag", recipeETag(recipe.Version))
+	w.Write(data)
+}
+
+// DeleteRecipe godoc
+// @Summary Delete a recipe
+// @Description move a recipe to the trash
+// @ID deleterecipe
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        If-Match   header      string  true  "ETag of the recipe being changed"
+// @Success 204
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /recipes/{id} [delete]
+func (a *App) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	recipe := a.recipeForChange(w, r, idParam)
+	if recipe == nil {
+		return
+	}
+	deleted, err := a.Database.DeleteRecipe(idParam, recipe.Version)
+	if errors.Is(err, database.ErrVersionMismatch) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write(getErrorResponse("The recipe has been changed since you loaded it"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete recipe"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TrashedRecipes godoc
+// @Summary Get deleted recipes
+// @Description get the recipes in the trash that the caller can restore: their own, or all of them for an admin
+// @ID trashedrecipes
+// @Produce json
+// @Success 200 {object} []model.TrashedRecipe
+// @Failure 500 {object} ErrorResponse
+// @Router /recipes/trash [get]
+func (a *App) getTrashedRecipes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	recipes := a.Database.AllTrashedRecipes()
+	if recipes == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load the trash"))
+		return
+	}
+	user := currentUser(r)
+	recipes = slices.DeleteFunc(recipes, func(recipe *model.TrashedRecipe) bool {
+		return !canModify(user, recipe.OwnerID)
+	})
+	data, err := loadDataAsJSON(recipes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load recipe"))
+		return
+	}
+	w.Write(data)
+}
+
+// RestoreRecipe godoc
+// @Summary Restore a deleted recipe
+// @Description move a recipe from the trash back to the recipes
+// @ID restorerecipe
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Success 200 {object} model.ResolvedRecipe
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /recipes/{id}/restore [post]
+func (a *App) RestoreRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	trashed, err := a.Database.FindTrashedRecipe(idParam)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe in the trash"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to restore recipe"))
+		return
+	}
+	if !canModify(currentUser(r), trashed.OwnerID) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse("Only the owner of the recipe or an admin can restore it"))
+		return
+	}
+	restored, err := a.Database.RestoreRecipe(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to restore recipe"))
+		return
+	}
+	if !restored {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe in the trash"))
+		return
+	}
+	data, err := loadDataAsJSON(a.Database.FindRecipeByID(idParam))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load recipe"))
+		return
 	}
-	w.WriteHeader(http.StatusCreated)
 	w.Write(data)
 }
 
 // GenerateRecipes godoc
 // @Summary Generate recipe
-// @Description Generate recipes
+// @Description Generate recipes from the test data. Admin only, and only available when the server runs with PROFILE=development.
 // @ID generaterecipe
 // @Produce json
 // Accept json
 // @Success 201 {object} []model.Recipe
 // @Router /recipes/generate [post]
-func GenerateRecipes(w http.ResponseWriter, r *http.Request) {
+func (a *App) GenerateRecipes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// numRecipesToCreate := 10
@@ -140,7 +720,7 @@ func GenerateRecipes(w http.ResponseWriter, r *http.Request) {
 	fileContent, err := os.Open(filename)
 
 	if err != nil {
-		fmt.Errorf("failed to open file")
+		log.Print("failed to open file: ", err)
 		w.Write(getErrorResponse("Failed to open testdata file"))
 	}
 
@@ -153,14 +733,16 @@ func GenerateRecipes(w http.ResponseWriter, r *http.Request) {
 
 	for _, recipe := range testData {
 		recipeCopy := model.RecipeWithoutID{
-			Name:        recipe.Name,
-			Description: recipe.Description,
-			Steps:       recipe.Steps,
-			Category:    recipe.Category,
-			Ingredients: recipe.Ingredients,
+			Name:            recipe.Name,
+			Description:     recipe.Description,
+			Steps:           recipe.Steps,
+			Category:        recipe.Category,
+			Servings:        recipe.Servings,
+			OwnerID:         recipe.OwnerID,
+			IngredientLines: recipe.IngredientLines,
 		}
 		recipesCreated = append(recipesCreated, &recipe)
-		db.SaveRecipe(&recipeCopy)
+		a.Database.SaveRecipe(&recipeCopy)
 	}
 
 	w.WriteHeader(http.StatusCreated)
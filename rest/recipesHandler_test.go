@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rest/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestoreRecipe(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	editor, _ := signUpEditor(t, app, admin, "bob")
+
+	var recipe model.Recipe
+	w := request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{Name: "Soup", Category: model.CategoryMainCourse}, &recipe)
+	if w := request(t, app, "DELETE", "/recipes/"+recipe.ID, admin, w.Header().Get("ETag"), nil, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("deleting recipe: %d %s", w.Code, w.Body)
+	}
+
+	var trash []model.TrashedRecipe
+	request(t, app, "GET", "/recipes/trash", editor, "", nil, &trash)
+	if trash == nil || len(trash) != 0 {
+		t.Errorf("bob's trash = %v, want an empty list", trash)
+	}
+	request(t, app, "GET", "/recipes/trash", admin, "", nil, &trash)
+	if len(trash) != 1 || trash[0].ID != recipe.ID {
+		t.Errorf("alice's trash = %v, want the deleted recipe", trash)
+	}
+
+	if w := request(t, app, "POST", "/recipes/"+recipe.ID+"/restore", editor, "", nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("bob restoring alice's recipe = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := request(t, app, "POST", "/recipes/"+primitive.NewObjectID().Hex()+"/restore", admin, "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("restoring a recipe that is not in the trash = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := request(t, app, "POST", "/recipes/"+recipe.ID+"/restore", admin, "", nil, nil); w.Code != http.StatusOK {
+		t.Errorf("alice restoring her recipe = %d %s", w.Code, w.Body)
+	}
+}
+
+func TestGetRecipeETag(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var recipe model.Recipe
+	created := request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{Name: "Soup", Category: model.CategoryMainCourse, Servings: 2}, &recipe)
+	stored := created.Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		query       string
+		ifNoneMatch string
+		wantCode    int
+		wantETag    bool
+	}{
+		{"stored", "", stored, http.StatusNotModified, true},
+		{"scaled", "?servings=4", stored, http.StatusOK, true},
+		{"converted", "?units=imperial", stored, http.StatusOK, true},
+		{"invalid servings", "?servings=x", stored, http.StatusBadRequest, false},
+		{"invalid units", "?units=cubits", "", http.StatusBadRequest, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/recipes/"+recipe.ID+test.query, nil)
+			r.Header.Set("If-None-Match", test.ifNoneMatch)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, r)
+			if w.Code != test.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, test.wantCode)
+			}
+			if etag := w.Header().Get("ETag"); (etag != "") != test.wantETag {
+				t.Errorf("ETag = %q, want one: %v", etag, test.wantETag)
+			}
+		})
+	}
+
+	// The ETag of a scaled copy is not taken for the stored recipe.
+	scaled := request(t, app, "GET", "/recipes/"+recipe.ID+"?servings=4", "", "", nil, nil).Header().Get("ETag")
+	if scaled == stored {
+		t.Fatalf("scaled ETag = stored ETag %s", stored)
+	}
+	if w := request(t, app, "DELETE", "/recipes/"+recipe.ID, admin, scaled, nil, nil); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("deleting with the scaled ETag = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+}
@@ -0,0 +1,223 @@
+package rest
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"rest/model"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// recordRevision stores the state of a recipe after the caller changed it.
+// before is the recipe as it was, or nil for a new recipe; when a recipe
+// from before revisions were kept changes for the first time, its old state
+// is stored first so the change can be diffed. The change itself has
+// already been saved, so failures are only logged.
+func (a *App) recordRevision(r *http.Request, recipeID string, before *model.Recipe, after *model.RecipeWithoutID, message string) {
+	if before != nil {
+		revisions, err := a.Database.RecipeRevisions(recipeID)
+		if err != nil {
+			log.Print("failed to load revisions: ", err)
+			return
+		}
+		if len(revisions) == 0 {
+			baseline := &model.RecipeRevision{
+				RecipeID:  recipeID,
+				CreatedAt: time.Now().UTC(),
+				Message:   "Before revisions were kept",
+				Recipe:    before.WithoutID(),
+			}
+			if err := a.Database.SaveRevision(baseline); err != nil {
+				log.Print("failed to save revision: ", err)
+				return
+			}
+		}
+	}
+	revision := &model.RecipeRevision{
+		RecipeID:  recipeID,
+		CreatedAt: time.Now().UTC(),
+		Message:   message,
+		Recipe:    *after,
+	}
+	if user := currentUser(r); user != nil {
+		revision.AuthorID = user.ID
+		revision.AuthorName = user.Username
+	}
+	if err := a.Database.SaveRevision(revision); err != nil {
+		log.Print("failed to save revision: ", err)
+	}
+}
+
+// RecipeRevisions godoc
+// @Summary Get the revisions of a recipe
+// @Description get every revision of a recipe, oldest first, with who made it, when, and the change message
+// @ID reciperevisions
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Success 200 {object} []model.RecipeRevision
+// @Failure 404 {object} ErrorResponse
+// @Router /recipes/{id}/revisions [get]
+func (a *App) getRecipeRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	revisions, err := a.Database.RecipeRevisions(idParam)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load revisions"))
+		return
+	}
+	if len(revisions) == 0 && a.Database.FindRecipeDocumentByID(idParam) == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	data, err := loadDataAsJSON(revisions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load revisions"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetRecipeRevision godoc
+// @Summary Get a revision of a recipe
+// @Description get a recipe as it was in one revision
+// @ID getreciperevision
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        number   path      int  true  "Revision number"
+// @Success 200 {object} model.RecipeRevision
+// @Failure 404 {object} ErrorResponse
+// @Router /recipes/{id}/revisions/{number} [get]
+func (a *App) getRecipeRevision(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	revision := a.findRevision(w, chi.URLParam(r, "id"), chi.URLParam(r, "number"))
+	if revision == nil {
+		return
+	}
+	data, err := loadDataAsJSON(revision)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load revision"))
+		return
+	}
+	w.Write(data)
+}
+
+// DiffRecipeRevisions godoc
+// @Summary Compare two revisions of a recipe
+// @Description show what changed between two revisions of a recipe: name, description, category and servings, steps added or removed, and ingredients added, removed or with a changed quantity
+// @ID diffreciperevisions
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        from   query      int  false  "Older revision (default the one before to)"
+// @Param        to   query      int  false  "Newer revision (default the latest)"
+// @Success 200 {object} model.RecipeDiff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /recipes/{id}/revisions/diff [get]
+func (a *App) DiffRecipeRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	toParam := r.URL.Query().Get("to")
+	if toParam == "" {
+		revisions, err := a.Database.RecipeRevisions(idParam)
+		if err != nil {
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(getErrorResponse("Failed to load revisions"))
+			return
+		}
+		if len(revisions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(getErrorResponse("The recipe has no revisions"))
+			return
+		}
+		toParam = strconv.Itoa(revisions[len(revisions)-1].Number)
+	}
+	to := a.findRevision(w, idParam, toParam)
+	if to == nil {
+		return
+	}
+	fromParam := r.URL.Query().Get("from")
+	if fromParam == "" {
+		fromParam = strconv.Itoa(max(to.Number-1, 1))
+	}
+	from := a.findRevision(w, idParam, fromParam)
+	if from == nil {
+		return
+	}
+	diff := model.DiffRecipes(&from.Recipe, &to.Recipe)
+	diff.From = from.Number
+	diff.To = to.Number
+	for i, change := range diff.Ingredients {
+		if ingredient := a.Database.FindIngredientByID(change.IngredientID); ingredient != nil {
+			diff.Ingredients[i].Name = ingredient.Name
+		}
+	}
+	data, err := loadDataAsJSON(diff)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load diff"))
+		return
+	}
+	w.Write(data)
+}
+
+// RevertRecipe godoc
+// @Summary Revert a recipe
+// @Description change a recipe back to how it was in an earlier revision. This adds a new revision; later revisions are kept.
+// @ID revertrecipe
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        number   path      int  true  "Revision number"
+// @Param        If-Match   header      string  true  "ETag of the recipe being changed"
+// @Param        message   query      string  false  "Change message"
+// @Success 200 {object} model.ResolvedRecipe
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Router /recipes/{id}/revisions/{number}/revert [post]
+func (a *App) RevertRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	idParam := chi.URLParam(r, "id")
+	recipe := a.recipeForChange(w, r, idParam)
+	if recipe == nil {
+		return
+	}
+	revision := a.findRevision(w, idParam, chi.URLParam(r, "number"))
+	if revision == nil {
+		return
+	}
+	body := revision.Recipe
+	body.OwnerID = recipe.OwnerID
+	message := r.URL.Query().Get("message")
+	if message == "" {
+		message = fmt.Sprintf("Reverted to revision %d", revision.Number)
+	}
+	a.writeUpdatedRecipe(w, r, recipe, &body, message)
+}
+
+// findRevision loads a revision by its number given as a string. It writes
+// an error response and returns nil if there is no such revision.
+func (a *App) findRevision(w http.ResponseWriter, recipeID string, numberParam string) *model.RecipeRevision {
+	number, err := strconv.Atoi(numberParam)
+	if err != nil || number < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Revision numbers are positive integers"))
+		return nil
+	}
+	revision := a.Database.FindRevision(recipeID, number)
+	if revision == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse(fmt.Sprintf("Could not find revision %d", number)))
+		return nil
+	}
+	return revision
+}
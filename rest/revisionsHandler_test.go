@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"net/http"
+	"rest/model"
+	"testing"
+)
+
+func TestRevertRecipe(t *testing.T) {
+	app := newTestApp()
+	token, _ := signUp(t, app, "alice")
+
+	original := model.RecipeWithoutID{Name: "Soup", Category: model.CategoryMainCourse, Steps: []string{"Boil water"}}
+	var recipe model.Recipe
+	w := request(t, app, "POST", "/recipes", token, "", original, &recipe)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("adding recipe: %d %s", w.Code, w.Body)
+	}
+	path := "/recipes/" + recipe.ID
+	stale := w.Header().Get("ETag")
+	changed := model.RecipeWithoutID{Name: "Tomato soup", Category: model.CategoryMainCourse, Steps: []string{"Boil water", "Add tomatoes"}}
+	w = request(t, app, "PUT", path, token, stale, changed, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("replacing recipe: %d %s", w.Code, w.Body)
+	}
+	current := w.Header().Get("ETag")
+
+	var diff model.RecipeDiff
+	request(t, app, "GET", path+"/revisions/diff", token, "", nil, &diff)
+	if diff.From != 1 || diff.To != 2 || diff.Name == nil || len(diff.Steps) != 1 {
+		t.Errorf("diff = %+v, want revision 1 to 2 with the name and one step changed", diff)
+	}
+
+	if w := request(t, app, "POST", path+"/revisions/1/revert", token, "", nil, nil); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("reverting without If-Match = %d, want %d", w.Code, http.StatusPreconditionRequired)
+	}
+	if w := request(t, app, "POST", path+"/revisions/1/revert", token, stale, nil, nil); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("reverting with a stale If-Match = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := request(t, app, "POST", path+"/revisions/9/revert", token, current, nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("reverting to a missing revision = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	var reverted model.ResolvedRecipe
+	w = request(t, app, "POST", path+"/revisions/1/revert", token, current, nil, &reverted)
+	if w.Code != http.StatusOK {
+		t.Fatalf("reverting: %d %s", w.Code, w.Body)
+	}
+	if reverted.Name != original.Name || len(reverted.Steps) != 1 {
+		t.Errorf("reverted recipe = %+v, want %+v", reverted, original)
+	}
+
+	var revisions []model.RecipeRevision
+	request(t, app, "GET", path+"/revisions", token, "", nil, &revisions)
+	if len(revisions) != 3 {
+		t.Fatalf("got %d revisions, want 3", len(revisions))
+	}
+	last := revisions[2]
+	if last.Number != 3 || last.Message != "Reverted to revision 1" || last.Recipe.Name != original.Name || last.AuthorName != "alice" {
+		t.Errorf("revert revision = %+v", last)
+	}
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"rest/model"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,23 +17,122 @@ func (a *App) loadRoutes() {
 	// processing should be stopped.
 	router.Use(middleware.Timeout(60 * time.Second))
 
+	router.Use(a.authenticate)
+
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
+	router.Post("/auth/login", a.Login)
+	router.Route("/users", a.loadUserRoutes)
 	router.Route("/recipes", a.loadRecipeRoutes)
 	router.Route("/ingredients", a.loadIngredientRoutes)
+	router.Route("/substitutions", a.loadSubstitutionRoutes)
+	router.Route("/shopping-lists", a.loadShoppingListRoutes)
+	router.Route("/meal-plans", a.loadMealPlanRoutes)
+	router.Route("/pantry", a.loadPantryRoutes)
+	router.Group(func(router chi.Router) {
+		router.Use(rejectAPIKeys, requireUser)
+		router.Route("/api-keys", a.loadAPIKeyRoutes)
+	})
 
 	a.Router = router
 }
 
 func (a *App) loadRecipeRoutes(router chi.Router) {
-	router.Get("/", getAllRecipes)
-	router.Get("/{id}", getRecipeByID)
-	router.Post("/", AddRecipe)
-	router.Post("/generate", GenerateRecipes)
+	read := router.With(authorize(model.ScopeRecipesRead))
+	write := router.With(authorize(model.ScopeRecipesWrite))
+	read.Get("/", a.getAllRecipes)
+	// The trash only lists the caller's own recipes, which needs a login.
+	write.Get("/trash", a.getTrashedRecipes)
+	read.Get("/search", a.searchRecipes)
+	read.Get("/{id}", a.getRecipeByID)
+	// Finding cookable recipes only reads, even though it is a POST.
+	read.Post("/cookable", a.CookableRecipes)
+	read.Get("/{id}/revisions", a.getRecipeRevisions)
+	read.Get("/{id}/revisions/diff", a.DiffRecipeRevisions)
+	read.Get("/{id}/revisions/{number}", a.getRecipeRevision)
+	read.Get("/{id}/nutrition", a.RecipeNutrition)
+	read.Get("/{id}/substitutions", a.RecipeSubstitutions)
+	write.Post("/", a.AddRecipe)
+	write.Put("/{id}", a.ReplaceRecipe)
+	write.Patch("/{id}", a.PatchRecipe)
+	write.Delete("/{id}", a.DeleteRecipe)
+	write.Post("/{id}/restore", a.RestoreRecipe)
+	write.Post("/{id}/revisions/{number}/revert", a.RevertRecipe)
+	// Cooking takes the ingredients out of the pantry.
+	router.With(authorize(model.ScopePlannerWrite)).Post("/{id}/cooked", a.RecipeCooked)
+	router.With(authorize(model.ScopeAdminGenerate)).Post("/generate", a.developmentOnly(a.GenerateRecipes))
 }
 
 func (a *App) loadIngredientRoutes(router chi.Router) {
-	router.Get("/", getAllIngredients)
-	router.Post("/", AddIngredient)
-	router.Get("/{name}", getIngredientByName)
-	router.Post("/generate", GenerateIngredients)
+	read := router.With(authorize(model.ScopeIngredientsRead))
+	write := router.With(authorize(model.ScopeIngredientsWrite))
+	read.Get("/", a.getAllIngredients)
+	write.Post("/", a.AddIngredient)
+	// Reading lines only needs ingredients:write when it adds ingredients.
+	read.Post("/parse", a.ParseIngredients)
+	read.Get("/lookup", a.LookupIngredients)
+	read.Get("/{name}", a.getIngredientByName)
+	write.Put("/{id}", a.ReplaceIngredient)
+	write.Patch("/{id}", a.PatchIngredient)
+	write.Delete("/{id}", a.DeleteIngredient)
+	router.With(authorize(model.ScopeAdminGenerate)).Post("/generate", a.developmentOnly(a.GenerateIngredients))
+	router.With(authorize(model.ScopeAdminIngredients)).Post("/{id}/merge", a.MergeIngredients)
+}
+
+func (a *App) loadSubstitutionRoutes(router chi.Router) {
+	read := router.With(authorize(model.ScopeIngredientsRead))
+	write := router.With(authorize(model.ScopeIngredientsWrite))
+	read.Get("/", a.getAllSubstitutions)
+	write.Post("/", a.AddSubstitution)
+	read.Get("/{id}", a.getSubstitutionByID)
+	write.Delete("/{id}", a.DeleteSubstitution)
+}
+
+func (a *App) loadShoppingListRoutes(router chi.Router) {
+	read := router.With(authorize(model.ScopePlannerRead))
+	write := router.With(authorize(model.ScopePlannerWrite))
+	read.Get("/", a.getAllShoppingLists)
+	write.Post("/", a.AddShoppingList)
+	read.Get("/{id}", a.getShoppingListByID)
+	read.Get("/{id}/export", a.ExportShoppingList)
+	write.Patch("/{id}/items/{item}", a.CheckShoppingListItem)
+	write.Delete("/{id}", a.DeleteShoppingList)
+}
+
+func (a *App) loadMealPlanRoutes(router chi.Router) {
+	read := router.With(authorize(model.ScopePlannerRead))
+	write := router.With(authorize(model.ScopePlannerWrite))
+	read.Get("/", a.getAllMealPlans)
+	write.Post("/", a.AddMealPlan)
+	read.Get("/{id}", a.getMealPlanByID)
+	write.Put("/{id}", a.ReplaceMealPlan)
+	write.Delete("/{id}", a.DeleteMealPlan)
+	write.Post("/{id}/copy-week", a.CopyMealPlanWeek)
+	read.Get("/{id}/calendar.ics", a.ExportMealPlan)
+	write.Post("/{id}/shopping-list", a.MealPlanShoppingList)
+}
+
+func (a *App) loadPantryRoutes(router chi.Router) {
+	read := router.With(authorize(model.ScopePlannerRead))
+	write := router.With(authorize(model.ScopePlannerWrite))
+	read.Get("/", a.getAllPantryItems)
+	write.Post("/", a.AddPantryItem)
+	read.Get("/expiring", a.ExpiringPantryItems)
+	read.Get("/{id}", a.getPantryItemByID)
+	write.Put("/{id}", a.ReplacePantryItem)
+	write.Delete("/{id}", a.DeletePantryItem)
+}
+
+func (a *App) loadUserRoutes(router chi.Router) {
+	router.Post("/", a.Register)
+	router.Get("/me", a.getCurrentUser)
+	admin := router.With(authorize(model.ScopeAdminUsers))
+	admin.Get("/", a.getAllUsers)
+	admin.Put("/{id}/role", a.SetUserRole)
+}
+
+func (a *App) loadAPIKeyRoutes(router chi.Router) {
+	router.Get("/", a.getAllAPIKeys)
+	router.Post("/", a.AddAPIKey)
+	router.Get("/{id}", a.getAPIKeyByID)
+	router.Delete("/{id}", a.RevokeAPIKey)
 }
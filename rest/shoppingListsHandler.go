@@ -0,0 +1,387 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"rest/database"
+	"rest/model"
+	"rest/units"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// otherAisle groups ingredients that do not say where they are found.
+const otherAisle = "other"
+
+// AddShoppingList godoc
+// @Summary Create a shopping list
+// @Description combine the ingredients of several recipes into one saved shopping list. The same ingredient is merged into one line where its units can be converted, and lines are grouped by aisle. Recipe lines whose ingredient was deleted are listed under unresolved.
+// @ID addshoppinglist
+// @Produce json
+// Accept json
+// @Param  request   body  model.ShoppingListRequest  true  "Recipes to shop for"
+// @Success 201 {object} model.ShoppingList
+// @Failure 400 {object} ErrorResponse
+// @Router /shopping-lists [post]
+func (a *App) AddShoppingList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.ShoppingListRequest
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode shopping list"))
+		return
+	}
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	list, err := a.buildShoppingList(body.Recipes)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	a.writeNewShoppingList(w, r, list)
+}
+
+// writeNewShoppingList saves a list for the caller.
+func (a *App) writeNewShoppingList(w http.ResponseWriter, r *http.Request, list *model.ShoppingList) {
+	list.OwnerID = currentUser(r).ID
+	if err := a.Database.SaveShoppingList(list); err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save shopping list"))
+		return
+	}
+	data, err := loadDataAsJSON(list)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load shopping list"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// buildShoppingList adds up the ingredients of the recipes, scaled by their
+// multipliers. Lines whose ingredient was deleted are listed as unresolved.
+func (a *App) buildShoppingList(recipes []model.ShoppingListRecipe) (*model.ShoppingList, error) {
+	type line struct {
+		ingredient model.Ingredient
+		quantity   units.Quantity
+	}
+	var lines []*line
+	var unresolved []model.ShoppingListGap
+	byIngredient := map[string][]*line{}
+	for _, entry := range recipes {
+		recipe := a.Database.FindRecipeByID(entry.RecipeID)
+		if recipe == nil {
+			return nil, fmt.Errorf("unknown recipe %s", entry.RecipeID)
+		}
+		for _, recipeLine := range a.unresolvedLines(recipe) {
+			unresolved = append(unresolved, model.ShoppingListGap{
+				RecipeID:     recipe.ID,
+				RecipeName:   recipe.Name,
+				IngredientID: recipeLine.Ingredient.Hex(),
+				Quantity:     recipeLine.Quantity * entry.Multiplier,
+				Unit:         recipeLine.Unit,
+			})
+		}
+		for _, recipeLine := range recipe.IngredientLines {
+			ingredient := recipeLine.Ingredient
+			quantity := units.Quantity{Amount: recipeLine.Quantity * entry.Multiplier, Unit: recipeLine.Unit}
+			merged := false
+			for _, existing := range byIngredient[ingredient.ID] {
+				converted, err := units.Convert(quantity, existing.quantity.Unit, ingredient.Density)
+				if err == nil {
+					existing.quantity.Amount += converted.Amount
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				// Quantities that cannot be converted to any line of the
+				// ingredient so far, like cloves and grams of garlic, get
+				// a line of their own.
+				added := &line{ingredient: ingredient, quantity: quantity}
+				lines = append(lines, added)
+				byIngredient[ingredient.ID] = append(byIngredient[ingredient.ID], added)
+			}
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		ai, aj := aisleOf(lines[i].ingredient), aisleOf(lines[j].ingredient)
+		if ai != aj {
+			if ai == otherAisle || aj == otherAisle {
+				return aj == otherAisle
+			}
+			return ai < aj
+		}
+		return lines[i].ingredient.Name < lines[j].ingredient.Name
+	})
+	list := &model.ShoppingList{CreatedAt: time.Now().UTC(), Recipes: recipes, Aisles: []model.ShoppingListAisle{}, Unresolved: unresolved}
+	for i, line := range lines {
+		aisle := aisleOf(line.ingredient)
+		if len(list.Aisles) == 0 || list.Aisles[len(list.Aisles)-1].Aisle != aisle {
+			list.Aisles = append(list.Aisles, model.ShoppingListAisle{Aisle: aisle})
+		}
+		quantity := units.Simplify(line.quantity)
+		current := &list.Aisles[len(list.Aisles)-1]
+		current.Items = append(current.Items, model.ShoppingListItem{
+			ID:         i + 1,
+			Ingredient: line.ingredient,
+			Quantity:   quantity.Amount,
+			Unit:       quantity.Unit,
+		})
+	}
+	return list, nil
+}
+
+func aisleOf(ingredient model.Ingredient) string {
+	if ingredient.Aisle == "" {
+		return otherAisle
+	}
+	return strings.ToLower(ingredient.Aisle)
+}
+
+// AllShoppingLists godoc
+// @Summary Get all shopping lists
+// @Description get the caller's saved shopping lists, or every list for admins, oldest first
+// @ID allshoppinglists
+// @Produce json
+// @Success 200 {object} []model.ShoppingList
+// @Router /shopping-lists [get]
+func (a *App) getAllShoppingLists(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	lists := a.Database.AllShoppingLists()
+	if lists == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load shopping lists"))
+		return
+	}
+	user := currentUser(r)
+	lists = slices.DeleteFunc(lists, func(list *model.ShoppingList) bool {
+		return !canModify(user, list.OwnerID)
+	})
+	data, err := loadDataAsJSON(lists)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load shopping lists"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetShoppingList godoc
+// @Summary Get shopping list by ID
+// @Description get a shopping list by ID
+// @ID getshoppinglist
+// @Produce json
+// @Param        id   path      string  true  "Shopping list ID"
+// @Success 200 {object} model.ShoppingList
+// @Router /shopping-lists/{id} [get]
+func (a *App) getShoppingListByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	list := a.findShoppingList(w, r)
+	if list == nil {
+		return
+	}
+	data, err := loadDataAsJSON(list)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load shopping list"))
+		return
+	}
+	w.Write(data)
+}
+
+// findShoppingList loads the shopping list in the URL if the caller owns it.
+// Other users' lists are reported as missing, like their API keys. It
+// writes an error response and returns nil if there is no such list.
+func (a *App) findShoppingList(w http.ResponseWriter, r *http.Request) *model.ShoppingList {
+	list := a.Database.FindShoppingListByID(chi.URLParam(r, "id"))
+	if list == nil || !canModify(currentUser(r), list.OwnerID) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find shopping list"))
+		return nil
+	}
+	return list
+}
+
+// CheckShoppingListItem godoc
+// @Summary Check off a shopping list item
+// @Description check or uncheck one item of a shopping list
+// @ID checkshoppinglistitem
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "Shopping list ID"
+// @Param        item   path      int  true  "Item ID"
+// @Param  update   body  model.ShoppingListItemUpdate  true  "Checked state"
+// @Success 200 {object} model.ShoppingList
+// @Router /shopping-lists/{id}/items/{item} [patch]
+func (a *App) CheckShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	itemID, err := strconv.Atoi(chi.URLParam(r, "item"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find item"))
+		return
+	}
+	var body model.ShoppingListItemUpdate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode item"))
+		return
+	}
+	list := a.findShoppingList(w, r)
+	if list == nil {
+		return
+	}
+	list, err = a.Database.CheckShoppingListItem(list.ID, itemID, body.Checked)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find shopping list item"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to update shopping list"))
+		return
+	}
+	data, err := loadDataAsJSON(list)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load shopping list"))
+		return
+	}
+	w.Write(data)
+}
+
+// DeleteShoppingList godoc
+// @Summary Delete a shopping list
+// @Description delete a shopping list
+// @ID deleteshoppinglist
+// @Param        id   path      string  true  "Shopping list ID"
+// @Success 204
+// @Router /shopping-lists/{id} [delete]
+func (a *App) DeleteShoppingList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	list := a.findShoppingList(w, r)
+	if list == nil {
+		return
+	}
+	deleted, err := a.Database.DeleteShoppingList(list.ID)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete shopping list"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find shopping list"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportShoppingList godoc
+// @Summary Export a shopping list
+// @Description export a shopping list as plain text with check boxes, or as CSV
+// @ID exportshoppinglist
+// @Produce plain
+// @Produce text/csv
+// @Param        id   path      string  true  "Shopping list ID"
+// @Param        format   query      string  false  "text (default) or csv"
+// @Success 200 {string} string
+// @Router /shopping-lists/{id}/export [get]
+func (a *App) ExportShoppingList(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "csv" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("format must be text or csv"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	list := a.findShoppingList(w, r)
+	if list == nil {
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"shopping-list-%s.csv\"", list.ID))
+		w.Write(shoppingListCSV(list))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(shoppingListText(list))
+}
+
+func shoppingListText(list *model.ShoppingList) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Shopping list (%s)\n", list.CreatedAt.Format("2006-01-02"))
+	for _, aisle := range list.Aisles {
+		fmt.Fprintf(&buf, "\n%s\n", strings.ToUpper(aisle.Aisle))
+		for _, item := range aisle.Items {
+			box := "[ ]"
+			if item.Checked {
+				box = "[x]"
+			}
+			fmt.Fprintf(&buf, "%s %s %s\n", box, formatQuantity(item.Quantity, item.Unit), item.Ingredient.Name)
+		}
+	}
+	if len(list.Unresolved) > 0 || len(list.DeletedRecipes) > 0 {
+		buf.WriteString("\nNOT ON THIS LIST\n")
+		for _, gap := range list.Unresolved {
+			fmt.Fprintf(&buf, "%s of a deleted ingredient in %s\n", formatQuantity(gap.Quantity, gap.Unit), gap.RecipeName)
+		}
+		for _, recipeID := range list.DeletedRecipes {
+			fmt.Fprintf(&buf, "Ingredients of deleted recipe %s\n", recipeID)
+		}
+	}
+	return buf.Bytes()
+}
+
+func shoppingListCSV(list *model.ShoppingList) []byte {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"aisle", "ingredient", "quantity", "unit", "checked"})
+	for _, aisle := range list.Aisles {
+		for _, item := range aisle.Items {
+			writer.Write([]string{
+				aisle.Aisle,
+				item.Ingredient.Name,
+				strconv.FormatFloat(item.Quantity, 'f', -1, 64),
+				item.Unit,
+				strconv.FormatBool(item.Checked),
+			})
+		}
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// formatQuantity writes a quantity for people to read, like "200 g" or "2".
+func formatQuantity(quantity float64, unit string) string {
+	amount := strconv.FormatFloat(quantity, 'f', -1, 64)
+	if unit == "" {
+		return amount
+	}
+	return amount + " " + unit
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"io"
+	"rest/model"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShoppingListDeletedIngredient(t *testing.T) {
+	app := newTestApp()
+	admin, _ := signUp(t, app, "alice")
+	var flour, sugar model.Ingredient
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "flour"}, &flour)
+	request(t, app, "POST", "/ingredients", admin, "", model.IngredientWithoutID{Name: "sugar"}, &sugar)
+	flourID, _ := primitive.ObjectIDFromHex(flour.ID)
+	sugarID, _ := primitive.ObjectIDFromHex(sugar.ID)
+	var recipe model.Recipe
+	request(t, app, "POST", "/recipes", admin, "", model.RecipeWithoutID{
+		Name:     "Cake",
+		Category: model.CategoryMainCourse,
+		IngredientLines: []model.IngredientLine{
+			{Ingredient: flourID, IngredientMeta: model.IngredientMeta{Quantity: 200, Unit: "g"}},
+			{Ingredient: sugarID, IngredientMeta: model.IngredientMeta{Quantity: 100, Unit: "g"}},
+		},
+	}, &recipe)
+	if _, err := app.Database.DeleteIngredient(sugar.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var list model.ShoppingList
+	request(t, app, "POST", "/shopping-lists", admin, "", model.ShoppingListRequest{Recipes: []model.ShoppingListRecipe{{RecipeID: recipe.ID, Multiplier: 2}}}, &list)
+	if len(list.Aisles) != 1 || len(list.Aisles[0].Items) != 1 || list.Aisles[0].Items[0].Ingredient.ID != flour.ID {
+		t.Errorf("aisles = %+v, want only flour", list.Aisles)
+	}
+	want := []model.ShoppingListGap{{RecipeID: recipe.ID, RecipeName: "Cake", IngredientID: sugar.ID, Quantity: 200, Unit: "g"}}
+	if len(list.Unresolved) != 1 || list.Unresolved[0] != want[0] {
+		t.Errorf("Unresolved = %+v, want %+v", list.Unresolved, want)
+	}
+
+	w := request(t, app, "GET", "/shopping-lists/"+list.ID+"/export", admin, "", nil, nil)
+	text, _ := io.ReadAll(w.Body)
+	if !strings.Contains(string(text), "200 g of a deleted ingredient in Cake") {
+		t.Errorf("export = %q, want the deleted ingredient listed", text)
+	}
+}
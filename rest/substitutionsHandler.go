@@ -0,0 +1,347 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"rest/model"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxSubstitutionDepth limits how many substitutions are chained to replace
+// one ingredient, as in butter to margarine to vegetable oil.
+const maxSubstitutionDepth = 3
+
+// AllSubstitutions godoc
+// @Summary Get all substitutions
+// @Description get every ingredient substitution
+// @ID allsubstitutions
+// @Produce json
+// @Success 200 {object} []model.Substitution
+// @Router /substitutions [get]
+func (a *App) getAllSubstitutions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	substitutions := a.Database.AllSubstitutions()
+	if substitutions == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load substitutions"))
+		return
+	}
+	data, err := loadDataAsJSON(substitutions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load substitutions"))
+		return
+	}
+	w.Write(data)
+}
+
+// GetSubstitution godoc
+// @Summary Get substitution by ID
+// @Description get an ingredient substitution by ID
+// @ID getsubstitution
+// @Produce json
+// @Param        id   path      string  true  "Substitution ID"
+// @Success 200 {object} model.Substitution
+// @Failure 404 {object} ErrorResponse
+// @Router /substitutions/{id} [get]
+func (a *App) getSubstitutionByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	substitution := a.Database.FindSubstitutionByID(chi.URLParam(r, "id"))
+	if substitution == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find substitution"))
+		return
+	}
+	data, err := loadDataAsJSON(substitution)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load substitution"))
+		return
+	}
+	w.Write(data)
+}
+
+// AddSubstitution godoc
+// @Summary Add a substitution
+// @Description add a way to replace an ingredient with one or more others. The quantity of each replacement is the replaced quantity times its ratio, in the replaced unit unless the replacement has its own unit.
+// @ID addsubstitution
+// @Produce json
+// Accept json
+// @Param  substitution   body  model.SubstitutionWithoutID  true  "Substitution"
+// @Success 201 {object} model.Substitution
+// @Failure 400 {object} ErrorResponse
+// @Router /substitutions [post]
+func (a *App) AddSubstitution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.SubstitutionWithoutID
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode substitution"))
+		return
+	}
+	if err := a.validateSubstitution(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	body.OwnerID = currentUser(r).ID
+	substitution, err := a.Database.SaveSubstitution(&body)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to save substitution"))
+		return
+	}
+	data, err := loadDataAsJSON(substitution)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load substitution"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (a *App) validateSubstitution(substitution *model.SubstitutionWithoutID) error {
+	if err := substitution.Validate(); err != nil {
+		return err
+	}
+	if a.Database.FindIngredientByID(substitution.Ingredient.Hex()) == nil {
+		return errors.New("unknown ingredient")
+	}
+	for _, replacement := range substitution.Replacements {
+		if a.Database.FindIngredientByID(replacement.Ingredient.Hex()) == nil {
+			return errors.New("unknown replacement ingredient " + replacement.Ingredient.Hex())
+		}
+	}
+	return nil
+}
+
+// DeleteSubstitution godoc
+// @Summary Delete a substitution
+// @Description delete an ingredient substitution
+// @ID deletesubstitution
+// @Param        id   path      string  true  "Substitution ID"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /substitutions/{id} [delete]
+func (a *App) DeleteSubstitution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	substitution := a.Database.FindSubstitutionByID(chi.URLParam(r, "id"))
+	if substitution == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find substitution"))
+		return
+	}
+	if !canModify(currentUser(r), substitution.OwnerID) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(getErrorResponse("Only the owner of the substitution or an admin can delete it"))
+		return
+	}
+	deleted, err := a.Database.DeleteSubstitution(substitution.ID)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to delete substitution"))
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find substitution"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RecipeSubstitutions godoc
+// @Summary Get a variant of a recipe with ingredients substituted
+// @Description replace the ingredients of a recipe that are missing, contain one of the excluded allergens or do not suit the diet, using the substitutions between ingredients. Quantities are adjusted by the substitutions' ratios. Ingredients whose allergens are not known count as containing every allergen. Ingredients without a usable substitution are listed in unreplaced; optional ones are left out of the variant.
+// @ID recipesubstitutions
+// @Produce json
+// @Param        id   path      string  true  "Recipe ID"
+// @Param        missing   query      string  false  "Ingredients that are not available, by ID or name, comma separated"
+// @Param        exclude_allergens   query      string  false  "Replace ingredients with any of these allergens, comma separated"
+// @Param        diet   query      string  false  "Replace ingredients that do not suit this diet: vegan, vegetarian or pescatarian"
+// @Success 200 {object} model.RecipeVariant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /recipes/{id}/substitutions [get]
+func (a *App) RecipeSubstitutions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	recipe := a.Database.FindRecipeByID(chi.URLParam(r, "id"))
+	if recipe == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find recipe"))
+		return
+	}
+	ingredients := a.Database.AllIngredients()
+	substitutions := a.Database.AllSubstitutions()
+	if ingredients == nil || substitutions == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load substitutions"))
+		return
+	}
+	substituter := &substituter{
+		ingredients:   make(map[primitive.ObjectID]*model.Ingredient, len(ingredients)),
+		substitutions: map[primitive.ObjectID][]*model.Substitution{},
+		missing:       map[primitive.ObjectID]bool{},
+	}
+	for _, ingredient := range ingredients {
+		if ID, err := primitive.ObjectIDFromHex(ingredient.ID); err == nil {
+			substituter.ingredients[ID] = ingredient
+		}
+	}
+	for _, substitution := range substitutions {
+		substituter.substitutions[substitution.Ingredient] = append(substituter.substitutions[substitution.Ingredient], substitution)
+	}
+
+	var err error
+	if substituter.allergens, err = parseAllergens(r.URL.Query().Get("exclude_allergens")); err == nil {
+		substituter.diet, err = parseDiet(r.URL.Query().Get("diet"))
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	for _, name := range strings.Split(r.URL.Query().Get("missing"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		ingredient := a.Database.FindIngredientByID(name)
+		if ingredient == nil {
+			ingredient = a.Database.FindIngredientByName(name)
+		}
+		if ingredient == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(getErrorResponse("Unknown ingredient " + name))
+			return
+		}
+		ID, _ := primitive.ObjectIDFromHex(ingredient.ID)
+		substituter.missing[ID] = true
+	}
+
+	data, err := loadDataAsJSON(substituter.variant(recipe))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load recipe"))
+		return
+	}
+	w.Write(data)
+}
+
+// substituter replaces the ingredients of recipes that are missing, contain
+// an excluded allergen or do not suit a diet.
+type substituter struct {
+	ingredients   map[primitive.ObjectID]*model.Ingredient
+	substitutions map[primitive.ObjectID][]*model.Substitution
+	missing       map[primitive.ObjectID]bool
+	allergens     []model.Allergen
+	diet          model.Diet
+}
+
+// reason says why an ingredient has to be replaced. ok is false when it can
+// stay.
+func (s *substituter) reason(ID primitive.ObjectID, ingredient *model.Ingredient) (reason model.SubstitutionReason, ok bool) {
+	switch {
+	case s.missing[ID]:
+		return model.ReasonMissing, true
+	case slices.ContainsFunc(s.allergens, ingredient.HasAllergen):
+		return model.ReasonAllergen, true
+	case s.diet != "" && !ingredient.FitsDiet(s.diet):
+		return model.ReasonDiet, true
+	}
+	return "", false
+}
+
+// variant returns recipe with its ingredients replaced.
+func (s *substituter) variant(recipe *model.ResolvedRecipe) model.RecipeVariant {
+	variant := model.RecipeVariant{
+		Substitutions: []model.AppliedSubstitution{},
+		Unreplaced:    []model.UnreplacedIngredient{},
+		Complete:      true,
+	}
+	lines := make([]model.ResolvedIngredientLine, 0, len(recipe.IngredientLines))
+	for _, resolved := range recipe.IngredientLines {
+		ID, _ := primitive.ObjectIDFromHex(resolved.Ingredient.ID)
+		reason, replace := s.reason(ID, &resolved.Ingredient)
+		if !replace {
+			lines = append(lines, resolved)
+			continue
+		}
+		line := model.IngredientLine{Ingredient: ID, IngredientMeta: resolved.IngredientMeta, Note: resolved.Note, Optional: resolved.Optional, Group: resolved.Group}
+		replacements, notes, ok := s.replace(line, map[primitive.ObjectID]bool{ID: true}, 1)
+		if !ok {
+			variant.Unreplaced = append(variant.Unreplaced, model.UnreplacedIngredient{Line: resolved, Reason: reason, Omitted: resolved.Optional})
+			if !resolved.Optional {
+				lines = append(lines, resolved)
+				variant.Complete = false
+			}
+			continue
+		}
+		applied := model.AppliedSubstitution{Replaced: resolved, Reason: reason, Notes: notes}
+		for _, replacement := range replacements {
+			applied.With = append(applied.With, replacement.Resolve(*s.ingredients[replacement.Ingredient]))
+		}
+		lines = append(lines, applied.With...)
+		variant.Substitutions = append(variant.Substitutions, applied)
+	}
+	replaced := *recipe
+	replaced.IngredientLines = lines
+	replaced.Classification = model.ClassifyRecipe(lines, recipe.Classification.Unresolved)
+	variant.Recipe = &replaced
+	return variant
+}
+
+// replace finds the lines that replace line using the first substitution
+// whose replacements can all stay, or can be replaced in turn. visited holds
+// the ingredients already replaced on the way, so substitutions that lead
+// back to one of them are not followed.
+func (s *substituter) replace(line model.IngredientLine, visited map[primitive.ObjectID]bool, depth int) ([]model.IngredientLine, []string, bool) {
+	for _, substitution := range s.substitutions[line.Ingredient] {
+		var lines []model.IngredientLine
+		var notes []string
+		if substitution.Note != "" {
+			notes = append(notes, substitution.Note)
+		}
+		usable := true
+		for _, replacement := range substitution.Apply(line) {
+			ingredient := s.ingredients[replacement.Ingredient]
+			if ingredient == nil || visited[replacement.Ingredient] {
+				usable = false
+				break
+			}
+			if _, replace := s.reason(replacement.Ingredient, ingredient); !replace {
+				lines = append(lines, replacement)
+				continue
+			}
+			if depth >= maxSubstitutionDepth {
+				usable = false
+				break
+			}
+			visited[replacement.Ingredient] = true
+			further, furtherNotes, ok := s.replace(replacement, visited, depth+1)
+			delete(visited, replacement.Ingredient)
+			if !ok {
+				usable = false
+				break
+			}
+			lines = append(lines, further...)
+			notes = append(notes, furtherNotes...)
+		}
+		if usable {
+			return lines, notes, true
+		}
+	}
+	return nil, nil, false
+}
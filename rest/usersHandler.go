@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"rest/auth"
+	"rest/database"
+	"rest/model"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Register godoc
+// @Summary Create a user account
+// @Description create a user account. New accounts are viewers, except the first one, which becomes an admin. An admin can promote them with PUT /users/{id}/role.
+// @ID register
+// @Produce json
+// Accept json
+// @Param  credentials   body  model.Credentials  true  "Username and password"
+// @Success 201 {object} model.UserInfo
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users [post]
+func (a *App) Register(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.Credentials
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode credentials"))
+		return
+	}
+	body.Username = strings.ToLower(strings.TrimSpace(body.Username))
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	hash, err := auth.HashPassword(body.Password)
+	if errors.Is(err, auth.ErrPasswordTooShort) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to create user"))
+		return
+	}
+	// SaveUser makes the first user an admin.
+	user := &model.User{Username: body.Username, PasswordHash: hash, Role: model.RoleViewer, CreatedAt: time.Now().UTC()}
+	err = a.Database.SaveUser(user)
+	if errors.Is(err, database.ErrUsernameTaken) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write(getErrorResponse("A user with this username already exists"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to create user"))
+		return
+	}
+	data, err := loadDataAsJSON(user.Info())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load user"))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description exchange a username and password for a token. Send it as "Authorization: Bearer <token>" to make changes.
+// @ID login
+// @Produce json
+// Accept json
+// @Param  credentials   body  model.Credentials  true  "Username and password"
+// @Success 200 {object} model.TokenResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/login [post]
+func (a *App) Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.Credentials
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode credentials"))
+		return
+	}
+	user := a.Database.FindUserByUsername(strings.ToLower(strings.TrimSpace(body.Username)))
+	if user == nil || !auth.CheckPassword(user.PasswordHash, body.Password) {
+		unauthorized(w, "Wrong username or password")
+		return
+	}
+	token, claims, err := auth.NewToken(user.ID, a.Config.TokenTTL, a.Config.JWTKey)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to create token"))
+		return
+	}
+	data, err := loadDataAsJSON(model.TokenResponse{
+		Token:     token,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+		User:      user.Info(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load token"))
+		return
+	}
+	w.Write(data)
+}
+
+// CurrentUser godoc
+// @Summary Get the logged in user
+// @Description get the account the request's token belongs to
+// @ID currentuser
+// @Produce json
+// @Success 200 {object} model.UserInfo
+// @Failure 401 {object} ErrorResponse
+// @Router /users/me [get]
+func (a *App) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	user := currentUser(r)
+	if user == nil {
+		unauthorized(w, "Not logged in")
+		return
+	}
+	data, err := loadDataAsJSON(user.Info())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load user"))
+		return
+	}
+	w.Write(data)
+}
+
+// AllUsers godoc
+// @Summary Get users
+// @Description get every user account. Only admins can list users.
+// @ID allusers
+// @Produce json
+// @Success 200 {object} []model.UserInfo
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /users [get]
+func (a *App) getAllUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	users := a.Database.AllUsers()
+	if users == nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load users"))
+		return
+	}
+	infos := make([]model.UserInfo, 0, len(users))
+	for _, user := range users {
+		infos = append(infos, user.Info())
+	}
+	data, err := loadDataAsJSON(infos)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Could not load users"))
+		return
+	}
+	w.Write(data)
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description make a user a viewer, an editor or an admin. Only admins can change roles, and the last admin cannot be demoted.
+// @ID setuserrole
+// @Produce json
+// Accept json
+// @Param        id   path      string  true  "User ID"
+// @Param  role   body  model.RoleUpdate  true  "Role"
+// @Success 200 {object} model.UserInfo
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /users/{id}/role [put]
+func (a *App) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var body model.RoleUpdate
+
+	res := json.NewDecoder(r.Body).Decode(&body)
+	if res != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse("Failed to decode role"))
+		return
+	}
+	if err := body.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getErrorResponse(err.Error()))
+		return
+	}
+	user := a.Database.FindUserByID(chi.URLParam(r, "id"))
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find user"))
+		return
+	}
+	if user.IsAdmin() && body.Role != model.RoleAdmin && a.countAdmins() <= 1 {
+		w.WriteHeader(http.StatusConflict)
+		w.Write(getErrorResponse("The last admin cannot be demoted"))
+		return
+	}
+	err := a.Database.SetUserRole(user.ID, body.Role)
+	if errors.Is(err, database.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getErrorResponse("Could not find user"))
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to change role"))
+		return
+	}
+	user.Role = body.Role
+	data, err := loadDataAsJSON(user.Info())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getErrorResponse("Failed to load user"))
+		return
+	}
+	w.Write(data)
+}
+
+func (a *App) countAdmins() int {
+	admins := 0
+	for _, user := range a.Database.AllUsers() {
+		if user.IsAdmin() {
+			admins++
+		}
+	}
+	return admins
+}
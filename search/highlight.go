@@ -0,0 +1,81 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+	// snippetContext is roughly how many bytes of text to keep before the
+	// first match and after the last one.
+	snippetContext = 40
+	// maxSnippetMatchSpan limits how far apart the first and last highlighted
+	// match in a snippet may be.
+	maxSnippetMatchSpan = 160
+)
+
+// Highlight returns a snippet of text around the words that match the query,
+// with each match wrapped in <mark> tags. The text is HTML escaped, so the
+// snippet is safe to render as HTML. It returns false if nothing in the text
+// matches.
+func Highlight(text string, query string) (string, bool) {
+	queryTerms := map[string]bool{}
+	for _, term := range Terms(query) {
+		queryTerms[term] = true
+	}
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		if !queryTerms[token.Term] {
+			continue
+		}
+		if len(matches) > 0 && token.End-matches[0].Start > maxSnippetMatchSpan {
+			break
+		}
+		matches = append(matches, token)
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start := wordBoundaryBefore(text, matches[0].Start-snippetContext)
+	end := wordBoundaryAfter(text, matches[len(matches)-1].End+snippetContext)
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	position := start
+	for _, match := range matches {
+		snippet.WriteString(html.EscapeString(text[position:match.Start]))
+		snippet.WriteString(highlightStart)
+		snippet.WriteString(html.EscapeString(text[match.Start:match.End]))
+		snippet.WriteString(highlightEnd)
+		position = match.End
+	}
+	snippet.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), true
+}
+
+func wordBoundaryBefore(text string, position int) int {
+	if position <= 0 {
+		return 0
+	}
+	if space := strings.LastIndexByte(text[:position], ' '); space >= 0 {
+		return space + 1
+	}
+	return 0
+}
+
+func wordBoundaryAfter(text string, position int) int {
+	if position >= len(text) {
+		return len(text)
+	}
+	if space := strings.IndexByte(text[position:], ' '); space >= 0 {
+		return position + space
+	}
+	return len(text)
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+		found bool
+	}{
+		{
+			name:  "single match",
+			text:  "Toast the bread",
+			query: "bread",
+			want:  "Toast the <mark>bread</mark>",
+			found: true,
+		},
+		{
+			name:  "stemmed match keeps the original word",
+			text:  "Chop the tomatoes",
+			query: "tomato",
+			want:  "Chop the <mark>tomatoes</mark>",
+			found: true,
+		},
+		{
+			name:  "several matches",
+			text:  "Mix garlic with basil",
+			query: "basil garlic",
+			want:  "Mix <mark>garlic</mark> with <mark>basil</mark>",
+			found: true,
+		},
+		{
+			name:  "no match",
+			text:  "Toast the bread",
+			query: "garlic",
+			found: false,
+		},
+		{
+			name:  "markup in the text is escaped",
+			text:  `<img src=x onerror="alert(1)"> bread & <script>`,
+			query: "bread",
+			want:  `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>bread</mark> &amp; &lt;script&gt;`,
+			found: true,
+		},
+		{
+			name:  "markup in the match is escaped",
+			text:  "<b>bread</b>",
+			query: "bread",
+			want:  "&lt;b&gt;<mark>bread</mark>&lt;/b&gt;",
+			found: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := Highlight(test.text, test.query)
+			if found != test.found || got != test.want {
+				t.Errorf("Highlight(%q, %q) = %q, %v, want %q, %v", test.text, test.query, got, found, test.want, test.found)
+			}
+		})
+	}
+}
+
+func TestHighlightTrimsLongText(t *testing.T) {
+	text := strings.Repeat("stir ", 30) + "add the basil " + strings.Repeat("stir ", 30)
+	got, found := Highlight(text, "basil")
+	if !found {
+		t.Fatalf("Highlight found no match in %q", text)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("Highlight(...) = %q, want a snippet with ellipses on both ends", got)
+	}
+	if !strings.Contains(got, "<mark>basil</mark>") {
+		t.Errorf("Highlight(...) = %q, want basil highlighted", got)
+	}
+	if len(got) >= len(text) {
+		t.Errorf("Highlight(...) returned %d bytes, want fewer than the %d in the text", len(got), len(text))
+	}
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// Field is a piece of a document's text. Matches in fields with a higher
+// weight count for more.
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// Hit is a document that matched a query, with its relevance score.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Index is an in-memory inverted index from terms to the documents that
+// contain them. It is not safe for concurrent writes.
+type Index struct {
+	// postings maps a term to the weighted term frequency in each document.
+	postings  map[string]map[string]float64
+	documents map[string]bool
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings:  map[string]map[string]float64{},
+		documents: map[string]bool{},
+	}
+}
+
+func (i *Index) Add(ID string, fields []Field) {
+	i.documents[ID] = true
+	for _, field := range fields {
+		for _, token := range Tokenize(field.Text) {
+			if i.postings[token.Term] == nil {
+				i.postings[token.Term] = map[string]float64{}
+			}
+			i.postings[token.Term][ID] += field.Weight
+		}
+	}
+}
+
+// Search returns the documents that contain at least one query term, best
+// match first. Each term contributes its saturated term frequency times its
+// inverse document frequency, so rare terms and repeated matches rank
+// higher without one frequent word dominating.
+func (i *Index) Search(query string) []Hit {
+	scores := map[string]float64{}
+	for _, term := range Terms(query) {
+		postings := i.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(i.documents))/float64(len(postings)))
+		for ID, frequency := range postings {
+			scores[ID] += idf * frequency / (frequency + 1)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for ID, score := range scores {
+		hits = append(hits, Hit{ID: ID, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ID < hits[b].ID
+	})
+	return hits
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex()
+	index.Add("bruschetta", []Field{
+		{Name: "name", Text: "Bruschetta", Weight: 3},
+		{Name: "steps", Text: "Mix tomatoes with garlic and basil", Weight: 1},
+	})
+	index.Add("tomato soup", []Field{
+		{Name: "name", Text: "Tomato soup", Weight: 3},
+		{Name: "steps", Text: "Simmer the tomatoes", Weight: 1},
+	})
+	index.Add("carbonara", []Field{
+		{Name: "name", Text: "Carbonara", Weight: 3},
+		{Name: "steps", Text: "Fry pancetta with garlic", Weight: 1},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tomato", []string{"tomato soup", "bruschetta"}},
+		{"garlic", []string{"bruschetta", "carbonara"}},
+		{"garlic basil", []string{"bruschetta", "carbonara"}},
+		{"saffron", []string{}},
+		{"the", []string{}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, hit := range index.Search(test.query) {
+			got = append(got, hit.ID)
+		}
+		if got == nil {
+			got = []string{}
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
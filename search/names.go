@@ -0,0 +1,63 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameKey folds a name for comparing it to other names: lower case, without
+// punctuation and with every word stemmed, so "Tomato", "tomatoes" and
+// "Tomatoes!" have the same key. Unlike Terms it keeps stop words and
+// repeated words, since they are part of the name.
+func NameKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = Stem(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// Distance is the number of edits that turn a into b, where an edit inserts,
+// deletes or replaces a character or swaps two adjacent ones.
+func Distance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	// rows[0] is the row before the previous one, for swaps.
+	rows := [3][]int{make([]int, len(t)+1), make([]int, len(t)+1), make([]int, len(t)+1)}
+	for j := range rows[1] {
+		rows[1][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		previous, current := rows[1], rows[2]
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				current[j] = min(current[j], rows[0][j-2]+1)
+			}
+		}
+		rows[0], rows[1], rows[2] = previous, current, rows[0]
+	}
+	return rows[1][len(t)]
+}
+
+// MaxTypos is how many edits a name of the given length may be off by and
+// still count as the same name: none for very short names, and more for
+// longer ones.
+func MaxTypos(name string) int {
+	switch length := len([]rune(name)); {
+	case length <= 3:
+		return 0
+	case length <= 5:
+		return 1
+	case length <= 10:
+		return 2
+	default:
+		return 3
+	}
+}
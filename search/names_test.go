@@ -0,0 +1,71 @@
+package search
+
+import "testing"
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Tomato", "tomato"},
+		{"tomatoes", "tomato"},
+		{"Tomatoes!", "tomato"},
+		{"Black  Pepper", "black pepper"},
+		{"salt and pepper", "salt and pepper"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := NameKey(test.name); got != test.want {
+			t.Errorf("NameKey(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"basil", "basil", 0},
+		{"", "basil", 5},
+		{"basil", "", 5},
+		{"basil", "basl", 1},
+		{"basil", "basils", 1},
+		{"basil", "bazil", 1},
+		{"basil", "bsail", 1},
+		{"pepper", "peper", 1},
+		{"garlic", "garlci", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+		{"crème", "creme", 1},
+	}
+	for _, test := range tests {
+		if got := Distance(test.a, test.b); got != test.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := Distance(test.b, test.a); got != test.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestMaxTypos(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"egg", 0},
+		{"salt", 1},
+		{"basil", 1},
+		{"garlic", 2},
+		{"parmesan", 2},
+		{"black pepper", 3},
+	}
+	for _, test := range tests {
+		if got := MaxTypos(test.name); got != test.want {
+			t.Errorf("MaxTypos(%q) = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package search implements the text analysis, inverted index and snippet
+// highlighting used for full-text recipe search on backends without a
+// native text index.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a term found in a text, with the byte offsets of the original
+// word it was derived from.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "into": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "the": true,
+	"then": true, "to": true, "until": true, "with": true,
+}
+
+// Tokenize splits text into words, folds them to lower case, drops stop
+// words and stems what is left.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = appendToken(tokens, text, start, i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = appendToken(tokens, text, start, len(text))
+	}
+	return tokens
+}
+
+func appendToken(tokens []Token, text string, start int, end int) []Token {
+	word := strings.ToLower(text[start:end])
+	if stopWords[word] {
+		return tokens
+	}
+	return append(tokens, Token{Term: Stem(word), Start: start, End: end})
+}
+
+// Terms returns the distinct terms in text, in the order they first appear.
+func Terms(text string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, token := range Tokenize(text) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
+
+// Stem reduces an English word to a stem by stripping common inflectional
+// suffixes, so that "tomatoes", "chopped" and "berries" match "tomato",
+// "chop" and "berry". It is a light stemmer rather than a full Porter
+// implementation; it only needs to map related forms to the same stem.
+func Stem(word string) string {
+	if utf8.RuneCountInString(word) <= 3 {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ies"):
+		word = strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+	case strings.HasSuffix(word, "s"):
+		word = strings.TrimSuffix(word, "s")
+	}
+	for _, suffix := range []struct {
+		suffix  string
+		minStem int
+	}{{"ing", 3}, {"ed", 3}, {"ly", 4}} {
+		stem := strings.TrimSuffix(word, suffix.suffix)
+		if stem != word && len(stem) >= suffix.minStem && hasVowel(stem) {
+			word = undouble(stem)
+			break
+		}
+	}
+	if strings.HasSuffix(word, "y") && len(word) > 3 && !isVowel(rune(word[len(word)-2])) {
+		word = strings.TrimSuffix(word, "y") + "i"
+	}
+	if strings.HasSuffix(word, "e") && len(word) > 3 {
+		word = strings.TrimSuffix(word, "e")
+	}
+	return word
+}
+
+// undouble turns a trailing double consonant into a single one, as in
+// "chopp" from "chopped".
+func undouble(word string) string {
+	n := len(word)
+	if n < 2 || word[n-1] != word[n-2] {
+		return word
+	}
+	switch last := rune(word[n-1]); {
+	case isVowel(last), last == 'l', last == 's', last == 'z':
+		return word
+	}
+	return word[:n-1]
+}
+
+func hasVowel(word string) bool {
+	for _, r := range word {
+		if isVowel(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
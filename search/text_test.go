@@ -0,0 +1,65 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"tomatoes", "tomato"},
+		{"tomato", "tomato"},
+		{"chopped", "chop"},
+		{"chopping", "chop"},
+		{"berries", "berri"},
+		{"berry", "berri"},
+		{"glasses", "glass"},
+		{"glass", "glass"},
+		{"hummus", "hummus"},
+		{"slices", "slic"},
+		{"sliced", "slic"},
+		{"quickly", "quick"},
+		{"egg", "egg"},
+		{"eggs", "egg"},
+		{"red", "red"},
+		{"bed", "bed"},
+		{"filled", "fill"},
+	}
+	for _, test := range tests {
+		if got := Stem(test.word); got != test.want {
+			t.Errorf("Stem(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "Chop the Tomatoes, then fry."
+	want := []Token{
+		{Term: "chop", Start: 0, End: 4},
+		{Term: "tomato", Start: 9, End: 17},
+		{Term: "fry", Start: 24, End: 27},
+	}
+	if got := Tokenize(text); !slices.Equal(got, want) {
+		t.Errorf("Tokenize(%q) = %v, want %v", text, got, want)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"tomato and tomatoes", []string{"tomato"}},
+		{"Fresh basil, fresh garlic", []string{"fresh", "basil", "garlic"}},
+		{"the and of", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := Terms(test.text); !slices.Equal(got, test.want) {
+			t.Errorf("Terms(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
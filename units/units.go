@@ -0,0 +1,301 @@
+// Package units knows the measuring units used in recipes: how to parse
+// their names, convert between them and present quantities in them.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Dimension int
+
+const (
+	// Count units measure things that are counted rather than weighed or
+	// measured, like cloves or slices. They never convert to other units.
+	Count Dimension = iota + 1
+	Mass
+	Volume
+)
+
+type System string
+
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// ParseSystem parses the name of a measuring system.
+func ParseSystem(name string) (System, bool) {
+	switch System(strings.ToLower(name)) {
+	case Metric:
+		return Metric, true
+	case Imperial:
+		return Imperial, true
+	}
+	return "", false
+}
+
+// Unit is a measuring unit. Factor is the size of the unit in the base unit
+// of its dimension: grams for mass and millilitres for volume.
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	System    System
+	Factor    float64
+	// Fractional units are measured with spoons and cups, so amounts in
+	// them are rounded to kitchen fractions rather than decimals.
+	Fractional bool
+	// Secondary units are understood but never chosen by Simplify or
+	// ToSystem, which stick to the units most recipes are written in.
+	Secondary bool
+}
+
+// knownUnits lists every unit, each dimension and system from the smallest
+// unit to the largest. The empty symbol is a plain count, as in "2 eggs".
+var knownUnits = []Unit{
+	{Symbol: "", Dimension: Count},
+	{Symbol: "piece", Dimension: Count},
+	{Symbol: "clove", Dimension: Count},
+	{Symbol: "slice", Dimension: Count},
+	{Symbol: "ball", Dimension: Count},
+	{Symbol: "bunch", Dimension: Count},
+	{Symbol: "can", Dimension: Count},
+	{Symbol: "pinch", Dimension: Count},
+	{Symbol: "mg", Dimension: Mass, System: Metric, Factor: 0.001},
+	{Symbol: "g", Dimension: Mass, System: Metric, Factor: 1},
+	{Symbol: "kg", Dimension: Mass, System: Metric, Factor: 1000},
+	{Symbol: "oz", Dimension: Mass, System: Imperial, Factor: 28.349523125},
+	{Symbol: "lb", Dimension: Mass, System: Imperial, Factor: 453.59237},
+	{Symbol: "ml", Dimension: Volume, System: Metric, Factor: 1},
+	{Symbol: "cl", Dimension: Volume, System: Metric, Factor: 10, Secondary: true},
+	{Symbol: "dl", Dimension: Volume, System: Metric, Factor: 100, Secondary: true},
+	{Symbol: "l", Dimension: Volume, System: Metric, Factor: 1000},
+	{Symbol: "tsp", Dimension: Volume, System: Imperial, Factor: 4.92892159375, Fractional: true},
+	{Symbol: "tbsp", Dimension: Volume, System: Imperial, Factor: 14.78676478125, Fractional: true},
+	{Symbol: "fl oz", Dimension: Volume, System: Imperial, Factor: 29.5735295625, Secondary: true},
+	{Symbol: "cup", Dimension: Volume, System: Imperial, Factor: 236.5882365, Fractional: true},
+	{Symbol: "pt", Dimension: Volume, System: Imperial, Factor: 473.176473, Secondary: true},
+	{Symbol: "qt", Dimension: Volume, System: Imperial, Factor: 946.352946, Secondary: true},
+	{Symbol: "gal", Dimension: Volume, System: Imperial, Factor: 3785.411784, Secondary: true},
+}
+
+// aliases maps other spellings of unit names to their symbol. Plurals that
+// only add an "s" or "es" do not need an entry.
+var aliases = map[string]string{
+	"pc":          "piece",
+	"pcs":         "piece",
+	"milligram":   "mg",
+	"gram":        "g",
+	"gr":          "g",
+	"kilogram":    "kg",
+	"kilo":        "kg",
+	"ounce":       "oz",
+	"pound":       "lb",
+	"lbs":         "lb",
+	"millilitre":  "ml",
+	"milliliter":  "ml",
+	"cc":          "ml",
+	"centilitre":  "cl",
+	"centiliter":  "cl",
+	"decilitre":   "dl",
+	"deciliter":   "dl",
+	"litre":       "l",
+	"liter":       "l",
+	"ltr":         "l",
+	"teaspoon":    "tsp",
+	"tablespoon":  "tbsp",
+	"tbs":         "tbsp",
+	"tbl":         "tbsp",
+	"fluid ounce": "fl oz",
+	"floz":        "fl oz",
+	"fl. oz":      "fl oz",
+	"c":           "cup",
+	"pint":        "pt",
+	"quart":       "qt",
+	"gallon":      "gal",
+}
+
+var (
+	ErrUnknownUnit = errors.New("unknown unit")
+	// ErrIncompatible is returned for conversions between dimensions that
+	// cannot be bridged, like count to mass, or volume to mass without a
+	// density.
+	ErrIncompatible = errors.New("incompatible units")
+)
+
+// Parse finds a unit by any of its names, ignoring case, surrounding
+// whitespace, a trailing period and plural endings.
+func Parse(name string) (Unit, bool) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	candidates := []string{name}
+	for _, suffix := range []string{"es", "s"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+			candidates = append(candidates, trimmed)
+		}
+	}
+	for _, candidate := range candidates {
+		if symbol, ok := aliases[candidate]; ok {
+			candidate = symbol
+		}
+		if unit, ok := lookup(candidate); ok {
+			return unit, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Normalize returns the symbol of the unit with the given name.
+func Normalize(name string) (string, error) {
+	unit, ok := Parse(name)
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownUnit, name)
+	}
+	return unit.Symbol, nil
+}
+
+func lookup(symbol string) (Unit, bool) {
+	for _, unit := range knownUnits {
+		if unit.Symbol == symbol {
+			return unit, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Quantity is an amount of something in a unit. The unit does not have to
+// be a known one, it just cannot be converted if it is not.
+type Quantity struct {
+	Amount float64
+	Unit   string
+}
+
+// Convert expresses the quantity in another unit. Density, in grams per
+// millilitre, is only needed to convert between volume and mass and may be
+// zero otherwise.
+func Convert(quantity Quantity, to string, density float64) (Quantity, error) {
+	from, ok := Parse(quantity.Unit)
+	if !ok {
+		return Quantity{}, fmt.Errorf("%w %q", ErrUnknownUnit, quantity.Unit)
+	}
+	target, ok := Parse(to)
+	if !ok {
+		return Quantity{}, fmt.Errorf("%w %q", ErrUnknownUnit, to)
+	}
+	if from.Dimension == Count || target.Dimension == Count {
+		// Counts have no factor, so they can only stay what they are.
+		if from.Symbol != target.Symbol {
+			return Quantity{}, fmt.Errorf("converting %s to %s: %w", quantity.Unit, to, ErrIncompatible)
+		}
+		return Quantity{Amount: quantity.Amount, Unit: target.Symbol}, nil
+	}
+	base, err := toBase(quantity.Amount*from.Factor, from.Dimension, target.Dimension, density)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("converting %s to %s: %w", quantity.Unit, to, err)
+	}
+	return Quantity{Amount: base / target.Factor, Unit: target.Symbol}, nil
+}
+
+// toBase moves an amount in the base unit of one of mass and volume to the
+// base unit of the other.
+func toBase(amount float64, from Dimension, to Dimension, density float64) (float64, error) {
+	switch {
+	case from == to:
+		return amount, nil
+	case density <= 0:
+		return 0, ErrIncompatible
+	case from == Volume:
+		return amount * density, nil
+	default:
+		return amount / density, nil
+	}
+}
+
+// ToSystem expresses the quantity in the given measuring system, keeping its
+// dimension, and simplifies it. Counts and unknown units are only rounded.
+func ToSystem(quantity Quantity, system System) Quantity {
+	unit, ok := Parse(quantity.Unit)
+	if !ok || unit.Dimension == Count || unit.System == system {
+		return Simplify(quantity)
+	}
+	base := quantity.Amount * unit.Factor
+	target, _ := smallestUnit(unit.Dimension, system)
+	return Simplify(Quantity{Amount: base / target.Factor, Unit: target.Symbol})
+}
+
+func smallestUnit(dimension Dimension, system System) (Unit, bool) {
+	for _, unit := range knownUnits {
+		if unit.Dimension == dimension && unit.System == system && !unit.Secondary {
+			return unit, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Scale multiplies the quantity by factor, then moves it to a larger unit
+// where that reads better and rounds it to a sensible precision.
+func Scale(quantity Quantity, factor float64) Quantity {
+	return Simplify(Quantity{Amount: quantity.Amount * factor, Unit: quantity.Unit})
+}
+
+// Simplify expresses the quantity in the largest unit of the same dimension
+// and system that keeps the amount at 1 or more, so 1000 g becomes 1 kg and
+// 16 tbsp becomes 1 cup, and rounds the amount. It never picks a smaller
+// unit than the quantity's own, so 0.5 g stays 0.5 g, except that
+// secondary units like cl move to one that recipes use. Zero, counts and
+// unknown units are only rounded.
+func Simplify(quantity Quantity) Quantity {
+	unit, ok := Parse(quantity.Unit)
+	if !ok || unit.Dimension == Count || quantity.Amount == 0 {
+		return Quantity{Amount: roundDecimal(quantity.Amount), Unit: quantity.Unit}
+	}
+	smallest := unit.Factor
+	if unit.Secondary {
+		smallest = 0
+	}
+	base := quantity.Amount * unit.Factor
+	var best Unit
+	for _, candidate := range knownUnits {
+		if candidate.Dimension != unit.Dimension || candidate.System != unit.System || candidate.Secondary || candidate.Factor < smallest {
+			continue
+		}
+		// knownUnits is ordered smallest first, so this ends on the largest
+		// unit that keeps the amount at 1 or more, or on the smallest unit
+		// allowed if none does.
+		if best.Symbol == "" || base/candidate.Factor >= 1-1e-9 {
+			best = candidate
+		}
+	}
+	return Quantity{Amount: round(base/best.Factor, best), Unit: best.Symbol}
+}
+
+func round(amount float64, unit Unit) float64 {
+	if unit.Fractional {
+		return roundFraction(amount)
+	}
+	return roundDecimal(amount)
+}
+
+// roundDecimal keeps three significant digits and at most two decimals:
+// 333.333 g becomes 333 g and 1.23456 kg becomes 1.23 kg.
+func roundDecimal(amount float64) float64 {
+	if amount == 0 {
+		return 0
+	}
+	digits := 3 - int(math.Floor(math.Log10(math.Abs(amount)))) - 1
+	digits = min(max(digits, 0), 2)
+	scale := math.Pow(10, float64(digits))
+	return math.Round(amount*scale) / scale
+}
+
+// roundFraction rounds to the nearest eighth, the smallest fraction on
+// measuring spoons and cups. Amounts that would round to nothing are kept
+// at one eighth.
+func roundFraction(amount float64) float64 {
+	rounded := math.Round(amount*8) / 8
+	if rounded == 0 && amount > 0 {
+		return 0.125
+	}
+	return rounded
+}
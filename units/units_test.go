@@ -0,0 +1,106 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		to       string
+		density  float64
+		want     Quantity
+	}{
+		{Quantity{1, "kg"}, "g", 0, Quantity{1000, "g"}},
+		{Quantity{1, "lb"}, "ounces", 0, Quantity{16, "oz"}},
+		{Quantity{1, "cup"}, "ml", 0, Quantity{236.5882365, "ml"}},
+		{Quantity{1, "cup"}, "g", 0.5, Quantity{118.29411825, "g"}},
+		{Quantity{100, "g"}, "ml", 0.5, Quantity{200, "ml"}},
+		{Quantity{2, "cloves"}, "clove", 0, Quantity{2, "clove"}},
+		{Quantity{3, ""}, "", 0, Quantity{3, ""}},
+	}
+	for _, test := range tests {
+		got, err := Convert(test.quantity, test.to, test.density)
+		if err != nil {
+			t.Errorf("Convert(%v, %q, %v) returned error %v", test.quantity, test.to, test.density, err)
+			continue
+		}
+		if got.Unit != test.want.Unit || math.Abs(got.Amount-test.want.Amount) > 1e-9 {
+			t.Errorf("Convert(%v, %q, %v) = %v, want %v", test.quantity, test.to, test.density, got, test.want)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		to       string
+		density  float64
+		want     error
+	}{
+		{Quantity{1, "cup"}, "g", 0, ErrIncompatible},
+		{Quantity{1, "cup"}, "g", -1, ErrIncompatible},
+		{Quantity{2, "clove"}, "g", 1, ErrIncompatible},
+		{Quantity{2, "clove"}, "slice", 0, ErrIncompatible},
+		{Quantity{1, "cup"}, "parsec", 0, ErrUnknownUnit},
+		{Quantity{1, "handful"}, "g", 0, ErrUnknownUnit},
+	}
+	for _, test := range tests {
+		if got, err := Convert(test.quantity, test.to, test.density); !errors.Is(err, test.want) {
+			t.Errorf("Convert(%v, %q, %v) = %v, %v, want error %v", test.quantity, test.to, test.density, got, err, test.want)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		want     Quantity
+	}{
+		{Quantity{1000, "g"}, Quantity{1, "kg"}},
+		// The unit the quantity is in is the smallest one used.
+		{Quantity{0.5, "kg"}, Quantity{0.5, "kg"}},
+		{Quantity{0.5, "g"}, Quantity{0.5, "g"}},
+		{Quantity{0.5, "cup"}, Quantity{0.5, "cup"}},
+		{Quantity{0, "g"}, Quantity{0, "g"}},
+		{Quantity{0, "cup"}, Quantity{0, "cup"}},
+		{Quantity{333.333, "g"}, Quantity{333, "g"}},
+		{Quantity{1500, "ml"}, Quantity{1.5, "l"}},
+		{Quantity{16, "tbsp"}, Quantity{1, "cup"}},
+		{Quantity{3, "tsp"}, Quantity{1, "tbsp"}},
+		{Quantity{0.01, "tsp"}, Quantity{0.125, "tsp"}},
+		// Secondary units are moved to one that recipes use.
+		{Quantity{20, "cl"}, Quantity{200, "ml"}},
+		// Counts and unknown units are only rounded.
+		{Quantity{2.333, "clove"}, Quantity{2.33, "clove"}},
+		{Quantity{1.23456, "handful"}, Quantity{1.23, "handful"}},
+	}
+	for _, test := range tests {
+		if got := Simplify(test.quantity); got != test.want {
+			t.Errorf("Simplify(%v) = %v, want %v", test.quantity, got, test.want)
+		}
+	}
+}
+
+func TestToSystem(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		system   System
+		want     Quantity
+	}{
+		{Quantity{1, "cup"}, Metric, Quantity{237, "ml"}},
+		{Quantity{1, "lb"}, Metric, Quantity{454, "g"}},
+		{Quantity{500, "g"}, Imperial, Quantity{1.1, "lb"}},
+		{Quantity{1, "l"}, Imperial, Quantity{4.25, "cup"}},
+		{Quantity{100, "ml"}, Imperial, Quantity{6.75, "tbsp"}},
+		{Quantity{500, "g"}, Metric, Quantity{500, "g"}},
+		{Quantity{2, "clove"}, Imperial, Quantity{2, "clove"}},
+	}
+	for _, test := range tests {
+		if got := ToSystem(test.quantity, test.system); got != test.want {
+			t.Errorf("ToSystem(%v, %s) = %v, want %v", test.quantity, test.system, got, test.want)
+		}
+	}
+}